@@ -0,0 +1,95 @@
+// Package handlers manages the different versions of the API.
+package handlers
+
+import (
+	"context"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"os"
+
+	v1 "github.com/dudakovict/blockchain/app/services/node/handlers/v1"
+	"github.com/dudakovict/blockchain/business/web/v1/mid"
+	"github.com/dudakovict/blockchain/foundation/blockchain/state"
+	"github.com/dudakovict/blockchain/foundation/web"
+	"go.uber.org/zap"
+)
+
+// MuxConfig contains all the mandatory systems required by handlers.
+type MuxConfig struct {
+	Shutdown chan os.Signal
+	Build    string
+	Log      *zap.SugaredLogger
+	State    *state.State
+}
+
+// PublicMux constructs a http.Handler with all application routes defined.
+func PublicMux(cfg MuxConfig) http.Handler {
+
+	// Construct the web.App which holds all routes as well as common Middleware.
+	app := web.NewApp(
+		cfg.Shutdown,
+		mid.Logger(cfg.Log),
+		mid.Errors(cfg.Log),
+		mid.Metrics(),
+		mid.Cors("*"),
+		mid.Panics(),
+	)
+
+	// Accept CORS 'OPTIONS' preflight requests so browser based wallets can
+	// talk to the node.
+	h := func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}
+	app.Handle(http.MethodOptions, "", "/*", h, mid.Cors("*"))
+
+	// Load the v1 routes.
+	v1.PublicRoutes(app, v1.Config{
+		Build: cfg.Build,
+		Log:   cfg.Log,
+		State: cfg.State,
+	})
+
+	return app
+}
+
+// PrivateMux constructs a http.Handler with all application routes defined
+// for the node to node communication.
+func PrivateMux(cfg MuxConfig) http.Handler {
+
+	// Construct the web.App which holds all routes as well as common Middleware.
+	app := web.NewApp(
+		cfg.Shutdown,
+		mid.Logger(cfg.Log),
+		mid.Errors(cfg.Log),
+		mid.Metrics(),
+		mid.Panics(),
+	)
+
+	// Load the v1 routes.
+	v1.PrivateRoutes(app, v1.Config{
+		Build: cfg.Build,
+		Log:   cfg.Log,
+		State: cfg.State,
+	})
+
+	return app
+}
+
+// DebugStandardLibraryMux registers all the debug routes from the standard library
+// into a new mux bypassing the use of the DefaultServerMux. Using the
+// DefaultServerMux would be a security risk since a dependency could inject a
+// handler into our service without us knowing it.
+func DebugStandardLibraryMux() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	// Register all the standard library debug endpoints.
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	return mux
+}
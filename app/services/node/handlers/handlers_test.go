@@ -0,0 +1,188 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dudakovict/blockchain/app/services/node/handlers"
+	v1 "github.com/dudakovict/blockchain/business/web/v1"
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+	"github.com/dudakovict/blockchain/foundation/blockchain/chaintest"
+	"github.com/dudakovict/blockchain/foundation/blockchain/genesis"
+	"github.com/dudakovict/blockchain/foundation/blockchain/peer"
+	"github.com/dudakovict/blockchain/foundation/blockchain/state"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage/memory"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+	"go.uber.org/zap"
+)
+
+// newMuxConfig constructs the systems for a node without peers holding its
+// chain in memory. The account of the key for seed 1 starts with a balance
+// of 1000.
+func newMuxConfig(t *testing.T) handlers.MuxConfig {
+	t.Helper()
+
+	gen := genesis.Genesis{
+		ChainID:    1,
+		Difficulty: 2,
+		GasPrice:   amount.New(1),
+		Balances:   map[string]amount.Amount{string(chaintest.AccountID(1)): amount.New(1000)},
+	}
+
+	st, err := state.New(state.Config{
+		BeneficiaryID: chaintest.AccountID(2),
+		MiningWorkers: 2,
+		Host:          "0.0.0.0:9080",
+		Storage:       memory.New(),
+		Genesis:       gen,
+		KnownPeers:    peer.NewPeerSet(),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg := handlers.MuxConfig{
+		Shutdown: make(chan os.Signal, 1),
+		Build:    "test",
+		Log:      zap.NewNop().Sugar(),
+		State:    st,
+	}
+
+	return cfg
+}
+
+// signedTx returns the JSON of a transfer of 100 from the account of the key
+// for seed 1.
+func signedTx(t *testing.T, nonce uint64) string {
+	t.Helper()
+
+	tx, err := transaction.NewTx(1, nonce, chaintest.AccountID(1), chaintest.AccountID(3), amount.New(100), amount.New(0), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	signed, err := tx.Sign(chaintest.Key(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := json.Marshal(signed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return string(data)
+}
+
+// =============================================================================
+
+func Test_PublicRoutes(t *testing.T) {
+	cfg := newMuxConfig(t)
+	mux := handlers.PublicMux(cfg)
+
+	type table struct {
+		testCaseID int
+		method     string
+		path       string
+		body       string
+		statusCode int
+		expected   string
+	}
+
+	tt := []table{
+		{testCaseID: 0, method: http.MethodGet, path: "/v1/genesis/list", statusCode: http.StatusOK, expected: `"chain_id": 1`},
+		{testCaseID: 1, method: http.MethodGet, path: "/v1/accounts/list/" + string(chaintest.AccountID(1)), statusCode: http.StatusOK, expected: `"balance": "0x3e8"`},
+		{testCaseID: 2, method: http.MethodGet, path: "/v1/accounts/list/0x1234", statusCode: http.StatusBadRequest},
+		{testCaseID: 3, method: http.MethodGet, path: "/v1/accounts/list/" + string(chaintest.AccountID(9)), statusCode: http.StatusNotFound},
+		{testCaseID: 4, method: http.MethodPost, path: "/v1/tx/submit", body: `{"nonce":`, statusCode: http.StatusBadRequest},
+		{testCaseID: 5, method: http.MethodPost, path: "/v1/tx/submit", body: signedTx(t, 1), statusCode: http.StatusOK},
+		{testCaseID: 6, method: http.MethodGet, path: "/v1/tx/uncommitted/list", statusCode: http.StatusOK, expected: `"nonce": 1`},
+		{testCaseID: 7, method: http.MethodGet, path: "/v1/blocks/1", statusCode: http.StatusNotFound},
+		{testCaseID: 8, method: http.MethodGet, path: "/v1/blocks/first", statusCode: http.StatusBadRequest},
+		{testCaseID: 9, method: http.MethodGet, path: "/v1/blocks/0x1234", statusCode: http.StatusNotFound},
+		{testCaseID: 10, method: http.MethodGet, path: "/v1/network/hashrate", statusCode: http.StatusNotFound},
+		{testCaseID: 11, method: http.MethodGet, path: "/v1/tx/uncommitted/content", statusCode: http.StatusOK, expected: `"pending": [`},
+		{testCaseID: 12, method: http.MethodGet, path: "/v1/node/info", statusCode: http.StatusOK, expected: `"genesis_hash": "` + cfg.State.Genesis().Hash() + `"`},
+		{testCaseID: 13, method: http.MethodGet, path: "/v1/node/info", statusCode: http.StatusOK, expected: `"consensus": "pow"`},
+	}
+
+	for _, tst := range tt {
+		r := httptest.NewRequest(tst.method, tst.path, strings.NewReader(tst.body))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+
+		if w.Code != tst.statusCode {
+			t.Errorf("[case:%d] error: expected status %d got %d: %s", tst.testCaseID, tst.statusCode, w.Code, w.Body)
+			continue
+		}
+
+		body, _ := io.ReadAll(w.Body)
+
+		// Failures are reported in the same form by every route.
+		if w.Code >= http.StatusBadRequest {
+			var er v1.ErrorResponse
+			if err := json.Unmarshal(body, &er); err != nil || er.Error == "" {
+				t.Errorf("[case:%d] error: expected an error response got %s", tst.testCaseID, body)
+			}
+			continue
+		}
+
+		if !strings.Contains(string(body), tst.expected) {
+			t.Errorf("[case:%d] error: expected the response to contain %s got %s", tst.testCaseID, tst.expected, body)
+		}
+	}
+}
+
+func Test_PrivateRoutes(t *testing.T) {
+	cfg := newMuxConfig(t)
+	mux := handlers.PrivateMux(cfg)
+
+	type table struct {
+		testCaseID int
+		method     string
+		path       string
+		body       string
+		statusCode int
+	}
+
+	tt := []table{
+		{testCaseID: 0, method: http.MethodGet, path: "/v1/node/status", statusCode: http.StatusOK},
+		{testCaseID: 1, method: http.MethodPost, path: "/v1/node/peers", body: `{"host":"0.0.0.0:9180"}`, statusCode: http.StatusNoContent},
+		{testCaseID: 2, method: http.MethodPost, path: "/v1/node/peers", body: `{}`, statusCode: http.StatusBadRequest},
+		{testCaseID: 3, method: http.MethodPost, path: "/v1/node/block/propose", body: `{"hash":"0x00"}`, statusCode: http.StatusBadRequest},
+		{testCaseID: 4, method: http.MethodGet, path: "/v1/node/block/list/1/latest", statusCode: http.StatusBadRequest},
+		{testCaseID: 5, method: http.MethodGet, path: "/v1/node/block/list/0/latest", statusCode: http.StatusBadRequest},
+		{testCaseID: 6, method: http.MethodPost, path: "/v1/node/tx/submit", body: signedTx(t, 1), statusCode: http.StatusNoContent},
+		{testCaseID: 7, method: http.MethodDelete, path: "/v1/node/tx/" + string(chaintest.AccountID(1)) + "/1", statusCode: http.StatusNoContent},
+		{testCaseID: 8, method: http.MethodDelete, path: "/v1/node/tx/" + string(chaintest.AccountID(1)) + "/1", statusCode: http.StatusNotFound},
+		{testCaseID: 9, method: http.MethodDelete, path: "/v1/node/tx/0x1234/1", statusCode: http.StatusBadRequest},
+		{testCaseID: 10, method: http.MethodDelete, path: "/v1/node/tx/" + string(chaintest.AccountID(1)) + "/first", statusCode: http.StatusBadRequest},
+		{testCaseID: 11, method: http.MethodPost, path: "/v1/node/mining/pause", statusCode: http.StatusNoContent},
+		{testCaseID: 12, method: http.MethodPost, path: "/v1/node/mining/resume", statusCode: http.StatusNoContent},
+		{testCaseID: 13, method: http.MethodPut, path: "/v1/node/mining/beneficiary", body: `{"beneficiary":"` + string(chaintest.AccountID(3)) + `"}`, statusCode: http.StatusNoContent},
+		{testCaseID: 14, method: http.MethodPut, path: "/v1/node/mining/beneficiary", body: `{"beneficiary":"0x1234"}`, statusCode: http.StatusBadRequest},
+	}
+
+	for _, tst := range tt {
+		r := httptest.NewRequest(tst.method, tst.path, strings.NewReader(tst.body))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+
+		if w.Code != tst.statusCode {
+			t.Errorf("[case:%d] error: expected status %d got %d: %s", tst.testCaseID, tst.statusCode, w.Code, w.Body)
+		}
+	}
+
+	if peers := cfg.State.Gossip().Peers().Copy(""); len(peers) != 1 || peers[0].Host != "0.0.0.0:9180" {
+		t.Errorf("error: expected the submitted peer to be known got %v", peers)
+	}
+
+	if cfg.State.MiningPaused() || cfg.State.BeneficiaryID() != chaintest.AccountID(3) {
+		t.Errorf("error: expected mining resumed for %s got paused %v for %s", chaintest.AccountID(3), cfg.State.MiningPaused(), cfg.State.BeneficiaryID())
+	}
+}
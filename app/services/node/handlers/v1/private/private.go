@@ -0,0 +1,254 @@
+// Package private maintains the group of handlers for node to node access.
+package private
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	v1 "github.com/dudakovict/blockchain/business/web/v1"
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/peer"
+	"github.com/dudakovict/blockchain/foundation/blockchain/proof"
+	"github.com/dudakovict/blockchain/foundation/blockchain/state"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+	"github.com/dudakovict/blockchain/foundation/web"
+	"go.uber.org/zap"
+)
+
+// Handlers manages the set of node endpoints.
+type Handlers struct {
+	Log   *zap.SugaredLogger
+	State *state.State
+}
+
+// mining represents the work this node has performed mining blocks.
+type mining struct {
+	Attempts     uint64        `json:"attempts"`
+	Duration     time.Duration `json:"duration"`
+	HashesPerSec float64       `json:"hashes_per_sec"`
+}
+
+// Status returns the current status of the node.
+func (h Handlers) Status(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	latestBlock := h.State.DB().LatestBlock()
+
+	status := struct {
+		peer.PeerStatus
+		Uncommitted  int             `json:"uncommitted"`
+		Beneficiary  acc.AccountID   `json:"beneficiary,omitempty"`
+		MiningPaused bool            `json:"mining_paused"`
+		HashRate     *proof.HashRate `json:"hash_rate,omitempty"`
+		Mining       *mining         `json:"mining,omitempty"`
+	}{
+		PeerStatus: peer.PeerStatus{
+			LatestBlockHash:   latestBlock.Hash(),
+			LatestBlockNumber: latestBlock.Header.Number,
+			ChainWork:         h.State.DB().ChainWork(),
+			KnownPeers:        h.State.Gossip().Peers().Copy(""),
+		},
+		Uncommitted:  h.State.Mempool().Count(),
+		Beneficiary:  h.State.BeneficiaryID(),
+		MiningPaused: h.State.MiningPaused(),
+	}
+
+	if hr, err := h.State.HashRate(); err == nil {
+		status.HashRate = &hr
+	}
+
+	if work := h.State.MiningWork(); work.Attempts > 0 {
+		status.Mining = &mining{
+			Attempts:     work.Attempts,
+			Duration:     work.Duration,
+			HashesPerSec: work.HashesPerSec(),
+		}
+	}
+
+	return web.Respond(ctx, w, status, http.StatusOK)
+}
+
+// PauseMining stops the node mining blocks until mining is resumed.
+func (h Handlers) PauseMining(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h.State.PauseMining()
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// ResumeMining lets the node mine blocks again.
+func (h Handlers) ResumeMining(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	h.State.ResumeMining()
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// SetBeneficiary changes the account receiving the rewards for the blocks
+// the node mines from now on. An empty beneficiary disables mining.
+func (h Handlers) SetBeneficiary(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var req struct {
+		Beneficiary string `json:"beneficiary"`
+	}
+	if err := web.Decode(r, &req); err != nil {
+		return v1.NewRequestError(fmt.Errorf("unable to decode payload: %w", err), http.StatusBadRequest)
+	}
+
+	var beneficiaryID acc.AccountID
+	if req.Beneficiary != "" {
+		if beneficiaryID, err = acc.ToAccountID(req.Beneficiary); err != nil {
+			return v1.NewRequestError(err, http.StatusBadRequest)
+		}
+	}
+
+	if err := h.State.SetBeneficiaryID(beneficiaryID); err != nil {
+		return v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	h.Log.Infow("set beneficiary", "traceid", v.TraceID, "beneficiary", beneficiaryID)
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// SubmitPeer is called by a node so it can be added to our list of peers.
+func (h Handlers) SubmitPeer(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var pr peer.Peer
+	if err := web.Decode(r, &pr); err != nil {
+		return v1.NewRequestError(fmt.Errorf("unable to decode payload: %w", err), http.StatusBadRequest)
+	}
+
+	if pr.Host == "" {
+		return v1.NewRequestError(errors.New("peer host is missing"), http.StatusBadRequest)
+	}
+
+	if h.State.Gossip().Peers().Add(pr) {
+		h.Log.Infow("adding peer", "traceid", v.TraceID, "host", pr.Host)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// EvictTransaction removes the transaction the account signed with the
+// specified nonce from the mempool.
+func (h Handlers) EvictTransaction(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	accountID, err := acc.ToAccountID(web.Param(r, "account"))
+	if err != nil {
+		return v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	nonce, err := strconv.ParseUint(web.Param(r, "nonce"), 10, 64)
+	if err != nil {
+		return v1.NewRequestError(fmt.Errorf("invalid nonce %q", web.Param(r, "nonce")), http.StatusBadRequest)
+	}
+
+	if !h.State.Mempool().Evict(accountID, nonce) {
+		return v1.NewRequestError(fmt.Errorf("transaction %s:%d not found", accountID, nonce), http.StatusNotFound)
+	}
+
+	h.Log.Infow("evict tran", "traceid", v.TraceID, "from", accountID, "nonce", nonce)
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// SubmitTransaction adds a transaction gossiped by a peer to the mempool.
+func (h Handlers) SubmitTransaction(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var tx transaction.BlockTx
+	if err := web.Decode(r, &tx); err != nil {
+		return v1.NewRequestError(fmt.Errorf("unable to decode payload: %w", err), http.StatusBadRequest)
+	}
+
+	h.Log.Infow("add peer tran", "traceid", v.TraceID, "sig:nonce", tx, "from", tx.FromID, "to", tx.ToID, "value", tx.Value, "tip", tx.Tip)
+
+	if err := h.State.UpsertNodeTransaction(tx); err != nil {
+		return v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// ProposeBlock applies a block mined by a peer. A block from a forked chain
+// triggers a sync with the longest chain.
+func (h Handlers) ProposeBlock(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	var blockData storage.BlockData
+	if err := web.Decode(r, &blockData); err != nil {
+		return v1.NewRequestError(fmt.Errorf("unable to decode payload: %w", err), http.StatusBadRequest)
+	}
+
+	b, err := storage.ToBlock(blockData)
+	if err != nil {
+		return v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	h.Log.Infow("propose block", "traceid", v.TraceID, "number", b.Header.Number, "hash", blockData.Hash)
+
+	if err := h.State.ProcessProposedBlock(b); err != nil {
+		if errors.Is(err, block.ErrChainForked) {
+			return v1.NewRequestError(err, http.StatusNotAcceptable)
+		}
+		return v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	return web.Respond(ctx, w, nil, http.StatusNoContent)
+}
+
+// BlocksByRange returns the blocks in the specified range so a peer can
+// sync its chain. The to parameter can be "latest".
+func (h Handlers) BlocksByRange(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	from, err := strconv.ParseUint(web.Param(r, "from"), 10, 64)
+	if err != nil || from == 0 {
+		return v1.NewRequestError(fmt.Errorf("invalid from block %q", web.Param(r, "from")), http.StatusBadRequest)
+	}
+
+	latest := h.State.DB().LatestBlock().Header.Number
+
+	to := latest
+	if toStr := web.Param(r, "to"); toStr != "latest" {
+		if to, err = strconv.ParseUint(toStr, 10, 64); err != nil {
+			return v1.NewRequestError(fmt.Errorf("invalid to block %q", toStr), http.StatusBadRequest)
+		}
+	}
+
+	if to > latest {
+		to = latest
+	}
+
+	if from > to {
+		return v1.NewRequestError(fmt.Errorf("invalid block range %d to %d", from, to), http.StatusBadRequest)
+	}
+
+	blocksData := make([]storage.BlockData, 0, to-from+1)
+	for num := from; num <= to; num++ {
+		b, err := h.State.DB().GetBlock(num)
+		if err != nil {
+			return err
+		}
+		blocksData = append(blocksData, storage.NewBlockData(b))
+	}
+
+	return web.Respond(ctx, w, blocksData, http.StatusOK)
+}
@@ -0,0 +1,156 @@
+package public
+
+import (
+	"encoding/json"
+	"time"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/mempool"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+)
+
+type act struct {
+	Account      acc.AccountID                   `json:"account"`
+	Balance      amount.Amount                   `json:"balance"`
+	Nonce        uint64                          `json:"nonce"`
+	CreatedAt    uint64                          `json:"created_at"`
+	Sent         uint64                          `json:"sent"`
+	Received     uint64                          `json:"received"`
+	MigratedTo   acc.AccountID                   `json:"migrated_to,omitempty"`
+	MigratedFrom acc.AccountID                   `json:"migrated_from,omitempty"`
+	Guardians    []acc.AccountID                 `json:"guardians,omitempty"`
+	Threshold    uint16                          `json:"threshold,omitempty"`
+	Recovery     *recovery                       `json:"recovery,omitempty"`
+	FeeGrants    map[acc.AccountID]amount.Amount `json:"fee_grants,omitempty"`
+}
+
+type recovery struct {
+	NewID     acc.AccountID   `json:"new_id"`
+	Approvals []acc.AccountID `json:"approvals"`
+	StartedAt uint64          `json:"started_at"`
+}
+
+type actInfo struct {
+	LatestBlock string `json:"latest_block"`
+	Uncommitted int    `json:"uncommitted"`
+	Accounts    []act  `json:"accounts"`
+}
+
+type blk struct {
+	Hash    string                `json:"hash"`
+	Header  block.BlockHeader     `json:"header"`
+	Rewards block.Rewards         `json:"rewards"`
+	Trans   []transaction.BlockTx `json:"trans"`
+}
+
+// nodeInfo reports the pruning mode as archive, since the node keeps every
+// block it has applied.
+type nodeInfo struct {
+	Version     string   `json:"version"`
+	Protocols   []string `json:"protocols"`
+	ChainID     uint16   `json:"chain_id"`
+	Consensus   string   `json:"consensus"`
+	Pruning     string   `json:"pruning"`
+	GenesisHash string   `json:"genesis_hash"`
+}
+
+type poolTx struct {
+	transaction.BlockTx
+	Age  time.Duration `json:"age"`
+	Size int           `json:"size"`
+}
+
+type poolAccount struct {
+	Pending []poolTx `json:"pending"`
+	Queued  []poolTx `json:"queued"`
+}
+
+type poolContent struct {
+	Count    int                           `json:"count"`
+	Bytes    int                           `json:"bytes"`
+	Accounts map[acc.AccountID]poolAccount `json:"accounts"`
+}
+
+func toAct(account acc.Account) act {
+	a := act{
+		Account:      account.AccountID,
+		Balance:      account.Balance,
+		Nonce:        account.Nonce,
+		CreatedAt:    account.CreatedAt,
+		Sent:         account.Sent,
+		Received:     account.Received,
+		MigratedTo:   account.MigratedTo,
+		MigratedFrom: account.MigratedFrom,
+		Guardians:    account.Guardians,
+		Threshold:    account.Threshold,
+		FeeGrants:    account.FeeGrants,
+	}
+
+	if account.Recovery != nil {
+		a.Recovery = &recovery{
+			NewID:     account.Recovery.NewID,
+			Approvals: account.Recovery.Approvals,
+			StartedAt: account.Recovery.StartedAt,
+		}
+	}
+
+	return a
+}
+
+func toBlk(b block.Block, rewards block.Rewards) blk {
+	bk := blk{
+		Hash:    b.Hash(),
+		Header:  b.Header,
+		Rewards: rewards,
+		Trans:   b.MerkleTree.Values(),
+	}
+
+	return bk
+}
+
+// toPoolContent reports the age of each transaction since the node received
+// it and its size as encoded for the API.
+func toPoolContent(content map[acc.AccountID]mempool.Content, now time.Time) (poolContent, error) {
+	pc := poolContent{
+		Accounts: make(map[acc.AccountID]poolAccount, len(content)),
+	}
+
+	toPoolTxs := func(trans []transaction.BlockTx) ([]poolTx, error) {
+		ptxs := make([]poolTx, len(trans))
+		for i, tx := range trans {
+			data, err := json.Marshal(tx)
+			if err != nil {
+				return nil, err
+			}
+
+			ptxs[i] = poolTx{
+				BlockTx: tx,
+				Age:     now.Sub(time.UnixMilli(int64(tx.TimeStamp))),
+				Size:    len(data),
+			}
+
+			pc.Count++
+			pc.Bytes += len(data)
+		}
+
+		return ptxs, nil
+	}
+
+	for accountID, c := range content {
+		pending, err := toPoolTxs(c.Pending)
+		if err != nil {
+			return poolContent{}, err
+		}
+
+		queued, err := toPoolTxs(c.Queued)
+		if err != nil {
+			return poolContent{}, err
+		}
+
+		pc.Accounts[accountID] = poolAccount{Pending: pending, Queued: queued}
+	}
+
+	return pc, nil
+}
@@ -0,0 +1,232 @@
+// Package public maintains the group of handlers for public access.
+package public
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/dudakovict/blockchain/business/web/v1"
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/state"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+	"github.com/dudakovict/blockchain/foundation/web"
+	"go.uber.org/zap"
+)
+
+// Handlers manages the set of public endpoints.
+type Handlers struct {
+	Build     string
+	Protocols []string
+	Log       *zap.SugaredLogger
+	State     *state.State
+}
+
+// SubmitTransaction adds new transactions to the mempool.
+func (h Handlers) SubmitTransaction(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	v, err := web.GetValues(ctx)
+	if err != nil {
+		return web.NewShutdownError("web value missing from context")
+	}
+
+	// Decode the JSON in the post call into a Signed transaction.
+	var signedTx transaction.SignedTx
+	if err := web.Decode(r, &signedTx); err != nil {
+		return v1.NewRequestError(fmt.Errorf("unable to decode payload: %w", err), http.StatusBadRequest)
+	}
+
+	h.Log.Infow("add tran", "traceid", v.TraceID, "sig:nonce", signedTx, "from", signedTx.FromID, "to", signedTx.ToID, "value", signedTx.Value, "tip", signedTx.Tip)
+
+	if err := h.State.UpsertWalletTransaction(signedTx); err != nil {
+		return v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	resp := struct {
+		Status string `json:"status"`
+	}{
+		Status: "transactions added to mempool",
+	}
+
+	return web.Respond(ctx, w, resp, http.StatusOK)
+}
+
+// GenesisList returns the genesis information.
+func (h Handlers) GenesisList(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	return web.Respond(ctx, w, h.State.Genesis(), http.StatusOK)
+}
+
+// NodeInfo returns the software the node runs and the chain it follows, so
+// tooling can check it's compatible with the node.
+func (h Handlers) NodeInfo(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	gen := h.State.Genesis()
+
+	info := nodeInfo{
+		Version:     h.Build,
+		Protocols:   h.Protocols,
+		ChainID:     gen.ChainID,
+		Consensus:   string(h.State.DB().Consensus()),
+		Pruning:     "archive",
+		GenesisHash: gen.Hash(),
+	}
+
+	return web.Respond(ctx, w, info, http.StatusOK)
+}
+
+// HashRate returns an estimate of the hash rate of the network from the
+// most recent blocks.
+func (h Handlers) HashRate(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	hr, err := h.State.HashRate()
+	if err != nil {
+		return v1.NewRequestError(err, http.StatusNotFound)
+	}
+
+	return web.Respond(ctx, w, hr, http.StatusOK)
+}
+
+// Accounts returns the current balances for all users.
+func (h Handlers) Accounts(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	accounts := h.State.DB().Copy()
+
+	acts := make([]act, 0, len(accounts))
+	for _, account := range accounts {
+		acts = append(acts, toAct(account))
+	}
+
+	sort.Slice(acts, func(i, j int) bool {
+		return acts[i].Account < acts[j].Account
+	})
+
+	ai := actInfo{
+		LatestBlock: h.State.DB().LatestBlock().Hash(),
+		Uncommitted: h.State.Mempool().Count(),
+		Accounts:    acts,
+	}
+
+	return web.Respond(ctx, w, ai, http.StatusOK)
+}
+
+// Account returns the current balance and nonce for the specified account.
+func (h Handlers) Account(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	accountID, err := acc.ToAccountID(web.Param(r, "account"))
+	if err != nil {
+		return v1.NewRequestError(err, http.StatusBadRequest)
+	}
+
+	account, err := h.State.DB().Query(accountID)
+	if err != nil {
+		return v1.NewRequestError(err, http.StatusNotFound)
+	}
+
+	return web.Respond(ctx, w, toAct(account), http.StatusOK)
+}
+
+// Blocks returns all the blocks in the chain.
+func (h Handlers) Blocks(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	latest := h.State.DB().LatestBlock().Header.Number
+
+	blocks := make([]blk, 0, latest)
+	for num := uint64(1); num <= latest; num++ {
+		b, err := h.State.DB().GetBlock(num)
+		if err != nil {
+			return err
+		}
+
+		rewards, err := h.State.DB().Rewards(num)
+		if err != nil {
+			return err
+		}
+
+		blocks = append(blocks, toBlk(b, rewards))
+	}
+
+	return web.Respond(ctx, w, blocks, http.StatusOK)
+}
+
+// Block returns the block with the specified number or hash.
+func (h Handlers) Block(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	id := web.Param(r, "block")
+
+	var num uint64
+	switch {
+	case strings.HasPrefix(id, "0x"):
+		n, err := h.blockNumberByHash(id)
+		if err != nil {
+			return err
+		}
+		num = n
+
+	default:
+		n, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return v1.NewRequestError(fmt.Errorf("invalid block number or hash %q", id), http.StatusBadRequest)
+		}
+		num = n
+	}
+
+	b, err := h.State.DB().GetBlock(num)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return v1.NewRequestError(err, http.StatusNotFound)
+		}
+		return err
+	}
+
+	rewards, err := h.State.DB().Rewards(num)
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, toBlk(b, rewards), http.StatusOK)
+}
+
+// UncommittedList returns the set of uncommitted transactions in the order a miner
+// would pick them.
+func (h Handlers) UncommittedList(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	trans := h.State.Mempool().PickBest(0)
+	return web.Respond(ctx, w, trans, http.StatusOK)
+}
+
+// UncommittedContent returns the transactions in the mempool grouped by
+// account into those that can be included in the next block and those
+// waiting for a transaction with a lower nonce.
+func (h Handlers) UncommittedContent(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	nextNonce := func(accountID acc.AccountID) uint64 {
+		account, err := h.State.DB().Query(accountID)
+		if err != nil {
+			return 1
+		}
+		return account.Nonce + 1
+	}
+
+	content, err := toPoolContent(h.State.Mempool().Content(nextNonce), time.Now())
+	if err != nil {
+		return err
+	}
+
+	return web.Respond(ctx, w, content, http.StatusOK)
+}
+
+// =============================================================================
+
+// blockNumberByHash searches the chain from the latest block for the block
+// with the specified hash.
+func (h Handlers) blockNumberByHash(hash string) (uint64, error) {
+	for num := h.State.DB().LatestBlock().Header.Number; num > 0; num-- {
+		b, err := h.State.DB().GetBlock(num)
+		if err != nil {
+			return 0, err
+		}
+
+		if b.Hash() == hash {
+			return num, nil
+		}
+	}
+
+	return 0, v1.NewRequestError(fmt.Errorf("block %s not found", hash), http.StatusNotFound)
+}
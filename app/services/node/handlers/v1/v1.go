@@ -0,0 +1,61 @@
+// Package v1 contains the full set of handler functions and routes
+// supported by the v1 web api.
+package v1
+
+import (
+	"net/http"
+
+	"github.com/dudakovict/blockchain/app/services/node/handlers/v1/private"
+	"github.com/dudakovict/blockchain/app/services/node/handlers/v1/public"
+	"github.com/dudakovict/blockchain/foundation/blockchain/state"
+	"github.com/dudakovict/blockchain/foundation/web"
+	"go.uber.org/zap"
+)
+
+const version = "v1"
+
+// Config contains all the mandatory systems required by handlers.
+type Config struct {
+	Build string
+	Log   *zap.SugaredLogger
+	State *state.State
+}
+
+// PublicRoutes binds all the version 1 public routes.
+func PublicRoutes(app *web.App, cfg Config) {
+	pbl := public.Handlers{
+		Build:     cfg.Build,
+		Protocols: []string{version},
+		Log:       cfg.Log,
+		State:     cfg.State,
+	}
+
+	app.Handle(http.MethodGet, version, "/genesis/list", pbl.GenesisList)
+	app.Handle(http.MethodGet, version, "/network/hashrate", pbl.HashRate)
+	app.Handle(http.MethodGet, version, "/node/info", pbl.NodeInfo)
+	app.Handle(http.MethodGet, version, "/accounts/list", pbl.Accounts)
+	app.Handle(http.MethodGet, version, "/accounts/list/:account", pbl.Account)
+	app.Handle(http.MethodGet, version, "/blocks/list", pbl.Blocks)
+	app.Handle(http.MethodGet, version, "/blocks/:block", pbl.Block)
+	app.Handle(http.MethodGet, version, "/tx/uncommitted/list", pbl.UncommittedList)
+	app.Handle(http.MethodGet, version, "/tx/uncommitted/content", pbl.UncommittedContent)
+	app.Handle(http.MethodPost, version, "/tx/submit", pbl.SubmitTransaction)
+}
+
+// PrivateRoutes binds all the version 1 private routes.
+func PrivateRoutes(app *web.App, cfg Config) {
+	prv := private.Handlers{
+		Log:   cfg.Log,
+		State: cfg.State,
+	}
+
+	app.Handle(http.MethodGet, version, "/node/status", prv.Status)
+	app.Handle(http.MethodPost, version, "/node/peers", prv.SubmitPeer)
+	app.Handle(http.MethodPost, version, "/node/mining/pause", prv.PauseMining)
+	app.Handle(http.MethodPost, version, "/node/mining/resume", prv.ResumeMining)
+	app.Handle(http.MethodPut, version, "/node/mining/beneficiary", prv.SetBeneficiary)
+	app.Handle(http.MethodPost, version, "/node/tx/submit", prv.SubmitTransaction)
+	app.Handle(http.MethodDelete, version, "/node/tx/:account/:nonce", prv.EvictTransaction)
+	app.Handle(http.MethodPost, version, "/node/block/propose", prv.ProposeBlock)
+	app.Handle(http.MethodGet, version, "/node/block/list/:from/:to", prv.BlocksByRange)
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dudakovict/blockchain/app/services/node/handlers"
+	"github.com/dudakovict/blockchain/business/web/metrics"
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/datadir"
+	"github.com/dudakovict/blockchain/foundation/blockchain/genesis"
+	"github.com/dudakovict/blockchain/foundation/blockchain/peer"
+	"github.com/dudakovict/blockchain/foundation/blockchain/state"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage/batch"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage/disk"
+	"github.com/dudakovict/blockchain/foundation/blockchain/worker"
+	"github.com/dudakovict/blockchain/foundation/logger"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.uber.org/zap"
+)
+
+// build is the git version of this program. It is set using build flags in the makefile.
+var build = "develop"
+
+func main() {
+
+	// Construct the application logger.
+	log, err := logger.New("NODE")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer log.Sync()
+
+	// Perform the startup and shutdown sequence.
+	if err := run(log); err != nil {
+		log.Errorw("startup", "ERROR", err)
+		log.Sync()
+		os.Exit(1)
+	}
+}
+
+func run(log *zap.SugaredLogger) error {
+
+	// =========================================================================
+	// GOMAXPROCS
+
+	log.Infow("startup", "GOMAXPROCS", runtime.GOMAXPROCS(0))
+
+	// =========================================================================
+	// Configuration
+
+	cfg := struct {
+		Web struct {
+			ReadTimeout     time.Duration
+			WriteTimeout    time.Duration
+			IdleTimeout     time.Duration
+			ShutdownTimeout time.Duration
+			DebugHost       string
+			PublicHost      string
+			PrivateHost     string
+		}
+		State struct {
+			Genesis       string
+			Beneficiary   string
+			SignerKey     string
+			MiningWorkers int
+			GasTarget     uint64
+			DBPath        string
+			BatchSize     int
+			BatchInterval time.Duration
+			OriginPeers   string
+			PeerInterval  time.Duration
+		}
+	}{}
+
+	flag.DurationVar(&cfg.Web.ReadTimeout, "web-read-timeout", 5*time.Second, "time allowed to read a request")
+	flag.DurationVar(&cfg.Web.WriteTimeout, "web-write-timeout", 10*time.Second, "time allowed to write a response")
+	flag.DurationVar(&cfg.Web.IdleTimeout, "web-idle-timeout", 120*time.Second, "time a keep-alive connection is kept open")
+	flag.DurationVar(&cfg.Web.ShutdownTimeout, "web-shutdown-timeout", 20*time.Second, "time allowed for requests to finish on shutdown")
+	flag.StringVar(&cfg.Web.DebugHost, "web-debug-host", "0.0.0.0:7080", "address for the debug endpoints")
+	flag.StringVar(&cfg.Web.PublicHost, "web-public-host", "0.0.0.0:8080", "address for the public endpoints")
+	flag.StringVar(&cfg.Web.PrivateHost, "web-private-host", "0.0.0.0:9080", "address for the private node endpoints")
+	flag.StringVar(&cfg.State.Genesis, "state-genesis", genesis.DefaultPath, "genesis file of the chain the node runs")
+	flag.StringVar(&cfg.State.Beneficiary, "state-beneficiary", "", "account that receives the rewards for mined blocks, mining is disabled without one")
+	flag.StringVar(&cfg.State.SignerKey, "state-signer-key", "", "file holding the authority's private key used to sign blocks under poa consensus")
+	flag.IntVar(&cfg.State.MiningWorkers, "state-mining-workers", 1, "number of goroutines searching for a nonce when mining")
+	flag.Uint64Var(&cfg.State.GasTarget, "state-gas-target", 0, "gas limit mined blocks move the chain towards, 0 keeps the current limit")
+	flag.StringVar(&cfg.State.DBPath, "state-db-path", "zblock/miner1/", "data directory of the node, holding the blocks, keys and known peers")
+	flag.IntVar(&cfg.State.BatchSize, "state-batch-size", 16, "number of blocks committed to disk together")
+	flag.DurationVar(&cfg.State.BatchInterval, "state-batch-interval", time.Second, "longest time a block waits to be committed to disk")
+	flag.StringVar(&cfg.State.OriginPeers, "state-origin-peers", "0.0.0.0:9080", "comma separated private hosts of the peers to start with")
+	flag.DurationVar(&cfg.State.PeerInterval, "state-peer-interval", 10*time.Second, "how often peer lists are exchanged and the chain is synced")
+	flag.Parse()
+
+	// =========================================================================
+	// App Starting
+
+	log.Infow("starting service", "version", build)
+	defer log.Infow("shutdown complete")
+
+	log.Infow("startup", "config", fmt.Sprintf("%+v", cfg))
+
+	// =========================================================================
+	// Blockchain Support
+
+	gen, err := genesis.Load(cfg.State.Genesis)
+	if err != nil {
+		return fmt.Errorf("loading genesis: %w", err)
+	}
+
+	// Mining is disabled on nodes without a beneficiary.
+	var beneficiaryID acc.AccountID
+	if cfg.State.Beneficiary != "" {
+		if beneficiaryID, err = acc.ToAccountID(cfg.State.Beneficiary); err != nil {
+			return fmt.Errorf("parsing beneficiary: %w", err)
+		}
+	}
+
+	// Blocks are only signed on poa chains, by the authorities.
+	var signerKey *ecdsa.PrivateKey
+	if cfg.State.SignerKey != "" {
+		if signerKey, err = crypto.LoadECDSA(cfg.State.SignerKey); err != nil {
+			return fmt.Errorf("loading signer key: %w", err)
+		}
+	}
+
+	// Open the data directory, which fails if another node is using it.
+	dataDir, err := datadir.Open(cfg.State.DBPath)
+	if err != nil {
+		return fmt.Errorf("opening data directory: %w", err)
+	}
+	defer dataDir.Close()
+
+	nodeKey, err := dataDir.NodeKey()
+	if err != nil {
+		return fmt.Errorf("loading node key: %w", err)
+	}
+	log.Infow("startup", "status", "data directory ready", "path", dataDir.Root(), "node", acc.PublicKeyToAccountID(nodeKey.PublicKey))
+
+	// Construct the storage the blocks are written to on disk. Blocks are
+	// committed in batches, with a write-ahead log keeping the blocks of a
+	// batch that hasn't been committed yet.
+	diskStore, err := disk.New(dataDir.ChainDir())
+	if err != nil {
+		return fmt.Errorf("constructing storage: %w", err)
+	}
+
+	store, err := batch.New(diskStore, batch.Config{
+		Size:     cfg.State.BatchSize,
+		Interval: cfg.State.BatchInterval,
+		WALPath:  dataDir.BlockWAL(),
+	})
+	if err != nil {
+		return fmt.Errorf("constructing storage: %w", err)
+	}
+
+	// The peers are the private hosts of the other nodes. This node is known
+	// to them by its own private host. The peers known when the node last
+	// stopped are added to the origin peers.
+	savedPeers, err := dataDir.LoadPeers()
+	if err != nil {
+		return fmt.Errorf("loading peers: %w", err)
+	}
+
+	peerSet := peer.NewPeerSet()
+	for _, host := range append(strings.Split(cfg.State.OriginPeers, ","), savedPeers...) {
+		if host = strings.TrimSpace(host); host != "" {
+			peerSet.Add(peer.New(host))
+		}
+	}
+	peerSet.Add(peer.New(cfg.Web.PrivateHost))
+
+	ev := func(v string, args ...any) {
+		log.Infow(fmt.Sprintf(v, args...), "traceid", "00000000-0000-0000-0000-000000000000")
+	}
+
+	// Panics recovered by the node's background goroutines are counted with
+	// the panics recovered by the handlers.
+	panicHandler := func(op string, rec any, trace []byte) {
+		metrics.AddBackgroundPanics()
+		log.Errorw("PANIC", "traceid", "00000000-0000-0000-0000-000000000000", "op", op, "panic", rec, "trace", string(trace))
+	}
+
+	// Construct the state, replaying the blocks already on disk.
+	st, err := state.New(state.Config{
+		BeneficiaryID: beneficiaryID,
+		SignerKey:     signerKey,
+		MiningWorkers: cfg.State.MiningWorkers,
+		GasTarget:     cfg.State.GasTarget,
+		Host:          cfg.Web.PrivateHost,
+		Storage:       store,
+		Genesis:       gen,
+		KnownPeers:    peerSet,
+		EvHandler:     ev,
+		PanicHandler:  panicHandler,
+	})
+	if err != nil {
+		return fmt.Errorf("constructing state: %w", err)
+	}
+	defer st.Shutdown()
+
+	// Save the known peers so the node can find them when it starts again.
+	defer func() {
+		hosts := []string{}
+		for _, pr := range peerSet.Copy(cfg.Web.PrivateHost) {
+			hosts = append(hosts, pr.Host)
+		}
+
+		if err := dataDir.SavePeers(hosts); err != nil {
+			log.Errorw("shutdown", "status", "unable to save peers", "ERROR", err)
+		}
+	}()
+
+	latestBlock := st.DB().LatestBlock()
+	log.Infow("startup", "status", "state ready", "latest block", latestBlock.Header.Number, "hash", latestBlock.Hash())
+
+	// The worker syncs with the peers, then mines and exchanges peers in
+	// the background until the state is shut down.
+	worker.Run(st, cfg.State.PeerInterval, ev)
+
+	// =========================================================================
+	// Start Debug Service
+
+	log.Infow("startup", "status", "debug router started", "host", cfg.Web.DebugHost)
+
+	// The Debug function returns a mux to listen and serve on for all the debug
+	// related endpoints. This includes the standard library endpoints.
+	debugMux := handlers.DebugStandardLibraryMux()
+
+	// Start the service listening for debug requests.
+	// Not concerned with shutting this down with load shedding.
+	go func() {
+		if err := http.ListenAndServe(cfg.Web.DebugHost, debugMux); err != nil {
+			log.Errorw("shutdown", "status", "debug router closed", "host", cfg.Web.DebugHost, "ERROR", err)
+		}
+	}()
+
+	// =========================================================================
+	// Service Start/Stop Support
+
+	// Make a channel to listen for an interrupt or terminate signal from the OS.
+	// Use a buffered channel because the signal package requires it.
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, syscall.SIGINT, syscall.SIGTERM)
+
+	// Make a channel to listen for errors coming from the listener. Use a
+	// buffered channel so the goroutine can exit if we don't collect this error.
+	serverErrors := make(chan error, 1)
+
+	muxCfg := handlers.MuxConfig{
+		Shutdown: shutdown,
+		Build:    build,
+		Log:      log,
+		State:    st,
+	}
+
+	// =========================================================================
+	// Start Public Service
+
+	log.Infow("startup", "status", "initializing V1 public API support")
+
+	// Construct a server to service the requests against the mux.
+	public := http.Server{
+		Addr:         cfg.Web.PublicHost,
+		Handler:      handlers.PublicMux(muxCfg),
+		ReadTimeout:  cfg.Web.ReadTimeout,
+		WriteTimeout: cfg.Web.WriteTimeout,
+		IdleTimeout:  cfg.Web.IdleTimeout,
+		ErrorLog:     zap.NewStdLog(log.Desugar()),
+	}
+
+	// Start the service listening for api requests.
+	go func() {
+		log.Infow("startup", "status", "public api router started", "host", public.Addr)
+		serverErrors <- public.ListenAndServe()
+	}()
+
+	// =========================================================================
+	// Start Private Service
+
+	log.Infow("startup", "status", "initializing V1 private API support")
+
+	// Construct a server to service the requests against the mux.
+	private := http.Server{
+		Addr:         cfg.Web.PrivateHost,
+		Handler:      handlers.PrivateMux(muxCfg),
+		ReadTimeout:  cfg.Web.ReadTimeout,
+		WriteTimeout: cfg.Web.WriteTimeout,
+		IdleTimeout:  cfg.Web.IdleTimeout,
+		ErrorLog:     zap.NewStdLog(log.Desugar()),
+	}
+
+	// Start the service listening for api requests.
+	go func() {
+		log.Infow("startup", "status", "private api router started", "host", private.Addr)
+		serverErrors <- private.ListenAndServe()
+	}()
+
+	// =========================================================================
+	// Shutdown
+
+	// Blocking main and waiting for shutdown.
+	select {
+	case err := <-serverErrors:
+		return fmt.Errorf("server error: %w", err)
+
+	case sig := <-shutdown:
+		log.Infow("shutdown", "status", "shutdown started", "signal", sig)
+		defer log.Infow("shutdown", "status", "shutdown complete", "signal", sig)
+
+		// Give outstanding requests a deadline for completion.
+		ctx, cancelPub := context.WithTimeout(context.Background(), cfg.Web.ShutdownTimeout)
+		defer cancelPub()
+
+		// Asking listener to shut down and shed load.
+		log.Infow("shutdown", "status", "shutdown private API started")
+		if err := private.Shutdown(ctx); err != nil {
+			private.Close()
+			return fmt.Errorf("could not stop private service gracefully: %w", err)
+		}
+
+		log.Infow("shutdown", "status", "shutdown public API started")
+		if err := public.Shutdown(ctx); err != nil {
+			public.Close()
+			if !errors.Is(err, http.ErrServerClosed) {
+				return fmt.Errorf("could not stop public service gracefully: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+// This program verifies a chain of block headers without any account state.
+// Every header gets the same checks the node makes against a block's
+// header, including a valid proof for the chain's consensus.
+// The headers are read from a file or fetched from a node.
+//
+//	headers -f blocks.json
+//	headers -u http://localhost:8080
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/genesis"
+	"github.com/dudakovict/blockchain/foundation/blockchain/proof"
+)
+
+var (
+	genesisPath string
+	filePath    string
+	nodeURL     string
+)
+
+func init() {
+	flag.StringVar(&genesisPath, "g", genesis.DefaultPath, "genesis file of the chain the headers belong to")
+	flag.StringVar(&filePath, "f", "", "file holding the headers, - reads from stdin")
+	flag.StringVar(&nodeURL, "u", "", "url of a node to fetch the blocks from")
+}
+
+func main() {
+	flag.Parse()
+
+	if err := run(); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func run() error {
+	gen, err := genesis.Load(genesisPath)
+	if err != nil {
+		return err
+	}
+
+	rules, err := gen.ChainRules()
+	if err != nil {
+		return err
+	}
+
+	r, err := open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	headers, err := decodeHeaders(r)
+	if err != nil {
+		return err
+	}
+
+	v := proof.NewHeaderVerifier(rules)
+	for _, header := range headers {
+		if err := v.Verify(header); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("verified %d headers, latest block %d\n", len(headers), v.Verified())
+	return nil
+}
+
+// open returns the source of the headers selected by the flags.
+func open() (io.ReadCloser, error) {
+	switch {
+	case filePath == "-":
+		return io.NopCloser(os.Stdin), nil
+
+	case filePath != "":
+		return os.Open(filePath)
+
+	case nodeURL != "":
+		url := strings.TrimSuffix(nodeURL, "/") + "/v1/blocks/list"
+
+		resp, err := http.Get(url)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("fetching %s: %s", url, resp.Status)
+		}
+
+		return resp.Body, nil
+	}
+
+	return nil, errors.New("either a file (-f) or a node url (-u) is required")
+}
+
+// decodeHeaders reads a stream of JSON values holding the headers in block
+// order. A value can be a single header or an array of them. Blocks as
+// returned by the node's API and as written to disk are unwrapped to their
+// header.
+func decodeHeaders(r io.Reader) ([]block.BlockHeader, error) {
+	var headers []block.BlockHeader
+
+	dec := json.NewDecoder(r)
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return headers, nil
+			}
+			return nil, err
+		}
+
+		values := []json.RawMessage{raw}
+		if strings.HasPrefix(strings.TrimSpace(string(raw)), "[") {
+			values = nil
+			if err := json.Unmarshal(raw, &values); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, value := range values {
+			header, err := decodeHeader(value)
+			if err != nil {
+				return nil, err
+			}
+			headers = append(headers, header)
+		}
+	}
+}
+
+// decodeHeader decodes a header, unwrapping it from a block if needed.
+func decodeHeader(value json.RawMessage) (block.BlockHeader, error) {
+	var wrapped struct {
+		Header *block.BlockHeader `json:"header"` // Node API.
+		Block  *block.BlockHeader `json:"block"`  // Block storage.
+	}
+	if err := json.Unmarshal(value, &wrapped); err != nil {
+		return block.BlockHeader{}, err
+	}
+
+	switch {
+	case wrapped.Header != nil:
+		return *wrapped.Header, nil
+	case wrapped.Block != nil:
+		return *wrapped.Block, nil
+	}
+
+	var header block.BlockHeader
+	if err := json.Unmarshal(value, &header); err != nil {
+		return block.BlockHeader{}, err
+	}
+
+	return header, nil
+}
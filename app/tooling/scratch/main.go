@@ -1,17 +1,36 @@
 package main
 
 import (
-	"fmt"
+	"flag"
 	"log"
 
 	"github.com/dudakovict/blockchain/foundation/blockchain/genesis"
 )
 
+var scenarioPath string
+
+func init() {
+	flag.StringVar(&scenarioPath, "f", "zblock/scenario.yaml", "scenario file to run")
+}
+
 func main() {
-	gen, err := genesis.Load()
+	flag.Parse()
+
+	if err := run(); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func run() error {
+	gen, err := genesis.Load(genesis.DefaultPath)
+	if err != nil {
+		return err
+	}
+
+	scenario, err := LoadScenario(scenarioPath)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	fmt.Println(gen)
+	return Run(gen, scenario)
 }
@@ -0,0 +1,455 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/currency"
+	"github.com/dudakovict/blockchain/foundation/blockchain/database"
+	"github.com/dudakovict/blockchain/foundation/blockchain/genesis"
+	"github.com/dudakovict/blockchain/foundation/blockchain/mempool"
+	"github.com/dudakovict/blockchain/foundation/blockchain/proof"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage/memory"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+	"github.com/ethereum/go-ethereum/crypto"
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario represents a script of steps executed against a fresh database
+// seeded from the genesis file.
+type Scenario struct {
+	Name        string    `yaml:"name"`
+	Difficulty  *uint16   `yaml:"difficulty"`  // Overrides the genesis difficulty so blocks mine quickly.
+	Authorities []string  `yaml:"authorities"` // Switches the chain to POA with these accounts signing blocks.
+	Accounts    []Account `yaml:"accounts"`
+	Steps       []Step    `yaml:"steps"`
+}
+
+// Account represents a named account used by the scenario. The private key
+// is derived from the seed so every run produces the same account.
+type Account struct {
+	Name    string `yaml:"name"`
+	Seed    string `yaml:"seed"`
+	Balance string `yaml:"balance"` // Added to the genesis balances, like "100" or "1.5 ether".
+}
+
+// Step represents a single action in the scenario. Exactly one of the
+// fields must be provided.
+type Step struct {
+	Send   *Send   `yaml:"send"`
+	Mine   *Mine   `yaml:"mine"`
+	Assert *Assert `yaml:"assert"`
+}
+
+// Send signs a transaction and adds it to the mempool. The
+// nonce is calculated from the account's state unless one is provided.
+type Send struct {
+	From  string `yaml:"from"`
+	To    string `yaml:"to"`
+	Type  string `yaml:"type"`
+	Value string `yaml:"value"`
+	Tip   string `yaml:"tip"`
+	Data  string `yaml:"data"`
+	Nonce uint64 `yaml:"nonce"`
+
+	// ExpiresAt is the last block number the transaction can be
+	// included in.
+	ExpiresAt uint64 `yaml:"expires_at"`
+
+	// FeeGranter is the account paying the gas through a fee grant.
+	FeeGranter string `yaml:"fee_granter"`
+}
+
+// Mine mines the specified number of blocks, each including the best
+// transactions from the mempool up to the number the genesis allows.
+type Mine struct {
+	Blocks      int    `yaml:"blocks"`
+	Beneficiary string `yaml:"beneficiary"`
+	GasTarget   uint64 `yaml:"gas_target"` // Gas limit the miner moves the chain towards.
+}
+
+// Assert checks the state of an account. Only the provided fields are
+// checked.
+type Assert struct {
+	Account string  `yaml:"account"`
+	Balance *string `yaml:"balance"`
+	Nonce   *uint64 `yaml:"nonce"`
+}
+
+// LoadScenario reads and parses the scenario file.
+func LoadScenario(path string) (Scenario, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Scenario{}, err
+	}
+
+	var scenario Scenario
+	if err := yaml.Unmarshal(content, &scenario); err != nil {
+		return Scenario{}, fmt.Errorf("parsing scenario %s: %w", path, err)
+	}
+
+	return scenario, nil
+}
+
+// =============================================================================
+
+// runner maintains the state required while executing a scenario.
+type runner struct {
+	genesis  genesis.Genesis
+	db       *database.Database
+	keys     map[string]*ecdsa.PrivateKey
+	accounts map[string]acc.AccountID
+	mempool  *mempool.Mempool
+}
+
+// Run executes the scenario against the specified genesis and stops at the
+// first step that fails.
+func Run(gen genesis.Genesis, scenario Scenario) error {
+	if scenario.Difficulty != nil {
+		gen.Difficulty = *scenario.Difficulty
+	}
+
+	r := runner{
+		genesis:  gen,
+		keys:     make(map[string]*ecdsa.PrivateKey),
+		accounts: make(map[string]acc.AccountID),
+		mempool:  mempool.New(gen.ChainID),
+	}
+
+	balances := make(map[string]amount.Amount)
+	for accountStr, balance := range gen.Balances {
+		balances[accountStr] = balance
+	}
+	r.genesis.Balances = balances
+
+	for _, account := range scenario.Accounts {
+		if err := r.addAccount(account); err != nil {
+			return fmt.Errorf("account %s: %w", account.Name, err)
+		}
+	}
+
+	if len(scenario.Authorities) > 0 {
+		r.genesis.Consensus = string(proof.ConsensusPOA)
+		r.genesis.Authorities = nil
+
+		for _, name := range scenario.Authorities {
+			accountID, err := r.accountID(name)
+			if err != nil {
+				return fmt.Errorf("authority %s: %w", name, err)
+			}
+			r.genesis.Authorities = append(r.genesis.Authorities, accountID)
+		}
+	}
+
+	db, err := database.New(r.genesis, memory.New())
+	if err != nil {
+		return err
+	}
+	r.db = db
+
+	// Report the transactions the database rejects while blocks are applied.
+	db.AddPostTxHook(database.PostTxHookFunc(func(b block.Block, tx transaction.BlockTx, txErr error) {
+		if txErr != nil {
+			fmt.Printf("mine: block[%d] tx rejected: %s\n", b.Header.Number, txErr)
+		}
+	}))
+
+	fmt.Printf("scenario: %s\n", scenario.Name)
+
+	for i, step := range scenario.Steps {
+		if err := r.step(step); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+
+	fmt.Println("scenario passed")
+	return nil
+}
+
+// addAccount derives the account's key and adds its balance to genesis.
+func (r *runner) addAccount(account Account) error {
+	seed := account.Seed
+	if seed == "" {
+		seed = account.Name
+	}
+
+	privateKey, err := crypto.ToECDSA(crypto.Keccak256([]byte(seed)))
+	if err != nil {
+		return err
+	}
+
+	accountID := acc.PublicKeyToAccountID(privateKey.PublicKey)
+	r.keys[account.Name] = privateKey
+	r.accounts[account.Name] = accountID
+
+	if account.Balance != "" {
+		balance, err := currency.ParseWithDenomination(account.Balance)
+		if err != nil {
+			return err
+		}
+		r.genesis.Balances[string(accountID)] = balance
+	}
+
+	return nil
+}
+
+// step executes a single step of the scenario.
+func (r *runner) step(step Step) error {
+	switch {
+	case step.Send != nil:
+		return r.send(*step.Send)
+	case step.Mine != nil:
+		return r.mine(*step.Mine)
+	case step.Assert != nil:
+		return r.assert(*step.Assert)
+	}
+
+	return errors.New("step has no action")
+}
+
+// send signs the transaction and adds it to the mempool.
+func (r *runner) send(send Send) error {
+	privateKey, exists := r.keys[send.From]
+	if !exists {
+		return fmt.Errorf("send: unknown account %q", send.From)
+	}
+
+	fromID := r.accounts[send.From]
+	toID, err := r.accountID(send.To)
+	if err != nil {
+		return fmt.Errorf("send: %w", err)
+	}
+
+	value, err := parseAmount(send.Value)
+	if err != nil {
+		return fmt.Errorf("send: value: %w", err)
+	}
+
+	tip, err := parseAmount(send.Tip)
+	if err != nil {
+		return fmt.Errorf("send: tip: %w", err)
+	}
+
+	nonce := send.Nonce
+	if nonce == 0 {
+		nonce = r.nextNonce(fromID)
+	}
+
+	tx, err := transaction.NewTx(r.genesis.ChainID, nonce, fromID, toID, value, tip, []byte(send.Data))
+	if err != nil {
+		return fmt.Errorf("send: %w", err)
+	}
+	tx.Type = send.Type
+	tx.ExpiresAt = send.ExpiresAt
+
+	if send.FeeGranter != "" {
+		granterID, err := r.accountID(send.FeeGranter)
+		if err != nil {
+			return fmt.Errorf("send: fee granter: %w", err)
+		}
+		tx.FeeGranter = granterID
+	}
+
+	signedTx, err := tx.Sign(privateKey)
+	if err != nil {
+		return fmt.Errorf("send: %w", err)
+	}
+
+	gasUnits, err := r.db.GasUnits(tx)
+	if err != nil {
+		return fmt.Errorf("send: %w", err)
+	}
+
+	if err := r.mempool.Upsert(transaction.NewBlockTx(signedTx, r.genesis.GasPrice, gasUnits)); err != nil {
+		return fmt.Errorf("send: %w", err)
+	}
+
+	fmt.Printf("send: %s -> %s value[%s] tip[%s] nonce[%d]\n", send.From, send.To, value, tip, nonce)
+	return nil
+}
+
+// mine mines the requested blocks and applies them to the database.
+func (r *runner) mine(mine Mine) error {
+	beneficiaryID, err := r.accountID(mine.Beneficiary)
+	if err != nil {
+		return fmt.Errorf("mine: %w", err)
+	}
+
+	blocks := mine.Blocks
+	if blocks == 0 {
+		blocks = 1
+	}
+
+	for i := 0; i < blocks; i++ {
+		if err := r.mineBlock(beneficiaryID, mine.GasTarget); err != nil {
+			return fmt.Errorf("mine: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// mineBlock mines a single block with the best transactions from the
+// mempool, validates it against the latest block and applies it like a node
+// would.
+func (r *runner) mineBlock(beneficiaryID acc.AccountID, gasTarget uint64) error {
+	latestBlock := r.db.LatestBlock()
+	number := latestBlock.Header.Number + 1
+
+	r.mempool.DeleteExpired(number)
+
+	ordering, err := block.ToOrderingPolicy(r.genesis.Ordering)
+	if err != nil {
+		return err
+	}
+
+	difficulty, err := r.db.NextDifficulty(latestBlock)
+	if err != nil {
+		return err
+	}
+
+	// Under POA a block can't be produced before its slot. Rather than wait
+	// like a node does, the scenario timestamps the block at its slot.
+	timeStamp := uint64(time.Now().UTC().UnixMilli())
+	if r.db.Consensus() == proof.ConsensusPOA && latestBlock.Header.TimeStamp > 0 {
+		slot := latestBlock.Header.TimeStamp + r.genesis.BlockInterval*1000
+		if timeStamp < slot {
+			timeStamp = slot
+		}
+	}
+
+	policy := block.BuildPolicy{
+		Ordering:      ordering,
+		GasLimit:      r.db.NextGasLimit(latestBlock, gasTarget),
+		MaxTrans:      int(r.genesis.TransPerBlock),
+		BeneficiaryID: beneficiaryID,
+		TimeStamp:     timeStamp,
+		Difficulty:    difficulty,
+		MiningReward:  r.db.MiningReward(number),
+	}
+
+	candidate, err := block.BuildBlock(latestBlock, r.mempool.PickBest(0), policy)
+	if err != nil {
+		return err
+	}
+
+	// The state roots are those of the accounts once the block is applied.
+	candidate, err = r.db.ExecuteBlock(candidate)
+	if err != nil {
+		return err
+	}
+
+	trans := candidate.MerkleTree.Values()
+	for _, tx := range trans {
+		r.mempool.Delete(tx)
+	}
+
+	b, err := r.seal(candidate)
+	if err != nil {
+		return err
+	}
+
+	if err := r.db.ApplyBlock(b); err != nil {
+		return err
+	}
+
+	fmt.Printf("mine: block[%d] hash[%s] trans[%d]\n", b.Header.Number, b.Hash(), len(trans))
+	return nil
+}
+
+// seal produces the proof the chain's consensus requires. Under POA the
+// block is signed by the authority in turn, which has to be one of the
+// scenario's accounts.
+func (r *runner) seal(candidate block.Block) (block.Block, error) {
+	if r.db.Consensus() != proof.ConsensusPOA {
+		b, _, err := proof.POW(context.Background(), candidate, 1)
+		return b, err
+	}
+
+	signerID := proof.InTurn(r.genesis.Authorities, candidate.Header.Number)
+	for name, accountID := range r.accounts {
+		if accountID == signerID {
+			return proof.POA(candidate, r.genesis.Authorities, r.keys[name])
+		}
+	}
+
+	return block.Block{}, fmt.Errorf("authority %s in turn isn't a scenario account", signerID)
+}
+
+// assert checks the account's state matches the expected values.
+func (r *runner) assert(assert Assert) error {
+	accountID, err := r.accountID(assert.Account)
+	if err != nil {
+		return fmt.Errorf("assert: %w", err)
+	}
+
+	account, err := r.db.Query(accountID)
+	if err != nil {
+		account = acc.New(accountID, amount.Amount{})
+	}
+
+	if assert.Balance != nil {
+		balance, err := parseAmount(*assert.Balance)
+		if err != nil {
+			return fmt.Errorf("assert: balance: %w", err)
+		}
+
+		if account.Balance.Cmp(balance) != 0 {
+			return fmt.Errorf("assert: %s balance, got %s, exp %s", assert.Account, account.Balance, balance)
+		}
+	}
+
+	if assert.Nonce != nil && account.Nonce != *assert.Nonce {
+		return fmt.Errorf("assert: %s nonce, got %d, exp %d", assert.Account, account.Nonce, *assert.Nonce)
+	}
+
+	fmt.Printf("assert: %s ok\n", assert.Account)
+	return nil
+}
+
+// =============================================================================
+
+// accountID returns the account for a scenario account name or an account
+// id written out in full.
+func (r *runner) accountID(name string) (acc.AccountID, error) {
+	if accountID, exists := r.accounts[name]; exists {
+		return accountID, nil
+	}
+
+	return acc.ToAccountID(name)
+}
+
+// nextNonce returns the next nonce for the account taking the mempool
+// into account. The nonce is worked out again for every transaction, since
+// a transaction that failed or expired doesn't use up its nonce.
+func (r *runner) nextNonce(accountID acc.AccountID) uint64 {
+	var nonce uint64
+	if account, err := r.db.Query(accountID); err == nil {
+		nonce = account.Nonce
+	}
+
+	for _, tx := range r.mempool.PickBest(0) {
+		if tx.FromID == accountID && tx.Nonce > nonce {
+			nonce = tx.Nonce
+		}
+	}
+
+	return nonce + 1
+}
+
+// parseAmount converts a scenario value into an amount. An empty value is
+// an amount of zero.
+func parseAmount(value string) (amount.Amount, error) {
+	if value == "" {
+		return amount.Amount{}, nil
+	}
+
+	return currency.ParseWithDenomination(value)
+}
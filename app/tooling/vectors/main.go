@@ -0,0 +1,167 @@
+// This program emits canonical signing test vectors so client
+// implementations in other languages can verify they produce the same
+// signing hash, signature and account as the signature package.
+package main
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+	"github.com/dudakovict/blockchain/foundation/blockchain/signature"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var output string
+
+func init() {
+	flag.StringVar(&output, "o", "", "file to write the vectors to, defaults to stdout")
+}
+
+// Vector represents a single signing test vector.
+type Vector struct {
+	Name        string         `json:"name"`
+	Version     int            `json:"canonical_version"`
+	PrivateKey  string         `json:"private_key"`
+	Tx          transaction.Tx `json:"tx"`
+	TxJSON      string         `json:"tx_json"`
+	SigningHash string         `json:"signing_hash"`
+	V           string         `json:"v"`
+	R           string         `json:"r"`
+	S           string         `json:"s"`
+	Signature   string         `json:"signature"`
+	Recovered   string         `json:"recovered"`
+}
+
+func main() {
+	flag.Parse()
+
+	if err := run(); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func run() error {
+	maxAmount, err := amount.Parse("0x" + strings.Repeat("f", 64))
+	if err != nil {
+		return err
+	}
+
+	cases := []struct {
+		name  string
+		tx    func(from acc.AccountID) transaction.Tx
+		keyID int
+	}{
+		{
+			name:  "transfer",
+			keyID: 1,
+			tx: func(from acc.AccountID) transaction.Tx {
+				return transaction.Tx{ChainID: 1, Nonce: 1, FromID: from, ToID: "0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76", Value: amount.New(100), Tip: amount.New(10)}
+			},
+		},
+		{
+			name:  "transfer_with_data",
+			keyID: 2,
+			tx: func(from acc.AccountID) transaction.Tx {
+				return transaction.Tx{ChainID: 1, Nonce: 7, FromID: from, ToID: "0x6Fe6CF3c8fF57c58d24BfC869668F48BCbDb3BD9", Value: amount.New(1), Data: []byte("hello")}
+			},
+		},
+		{
+			name:  "max_values",
+			keyID: 3,
+			tx: func(from acc.AccountID) transaction.Tx {
+				return transaction.Tx{ChainID: math.MaxUint16, Nonce: math.MaxUint64, FromID: from, ToID: "0xa988b1866EaBF72B4c53b592c97aAD8e4b9bDCC0", Value: maxAmount, Tip: maxAmount}
+			},
+		},
+		{
+			name:  "typed_rotate_key",
+			keyID: 4,
+			tx: func(from acc.AccountID) transaction.Tx {
+				return transaction.Tx{ChainID: 1, Type: transaction.TypeRotateKey, Nonce: 2, FromID: from, ToID: "0xF01813E4B85e178A83e29B8E7bF26BD830a25f32"}
+			},
+		},
+	}
+
+	vectors := make([]Vector, len(cases))
+	for i, c := range cases {
+		privateKey, err := vectorKey(c.keyID)
+		if err != nil {
+			return err
+		}
+
+		tx := c.tx(acc.PublicKeyToAccountID(privateKey.PublicKey))
+
+		vector, err := newVector(c.name, privateKey, tx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", c.name, err)
+		}
+		vectors[i] = vector
+	}
+
+	data, err := json.MarshalIndent(vectors, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		fmt.Println(string(data))
+		return nil
+	}
+
+	return os.WriteFile(output, data, 0644)
+}
+
+// vectorKey produces a deterministic private key so the vectors are
+// identical every time they are generated.
+func vectorKey(id int) (*ecdsa.PrivateKey, error) {
+	seed := crypto.Keccak256([]byte(fmt.Sprintf("blockchain-test-vector-%d", id)))
+	return crypto.ToECDSA(seed)
+}
+
+// newVector signs the transaction and captures every intermediate value.
+func newVector(name string, privateKey *ecdsa.PrivateKey, tx transaction.Tx) (Vector, error) {
+	txJSON, err := signature.Canonical(tx)
+	if err != nil {
+		return Vector{}, err
+	}
+
+	hash, err := signature.SigningHash(tx)
+	if err != nil {
+		return Vector{}, err
+	}
+
+	signedTx, err := tx.Sign(privateKey)
+	if err != nil {
+		return Vector{}, err
+	}
+
+	recovered, err := signature.FromAddress(tx, signedTx.V, signedTx.R, signedTx.S)
+	if err != nil {
+		return Vector{}, err
+	}
+
+	vector := Vector{
+		Name:        name,
+		Version:     signature.CanonicalVersion,
+		PrivateKey:  hexutil.Encode(crypto.FromECDSA(privateKey)),
+		Tx:          tx,
+		TxJSON:      string(txJSON),
+		SigningHash: hexutil.Encode(hash),
+		V:           hexutil.EncodeBig(signedTx.V),
+		R:           hexutil.EncodeBig(signedTx.R),
+		S:           hexutil.EncodeBig(signedTx.S),
+		Signature:   signedTx.SignatureString(),
+		Recovered:   recovered,
+	}
+
+	return vector, nil
+}
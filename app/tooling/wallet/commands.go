@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+	"github.com/dudakovict/blockchain/foundation/blockchain/currency"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// client is used for all the calls to the node.
+var client = http.Client{
+	Timeout: 10 * time.Second,
+}
+
+// generateCmd creates a new private key for the account. An existing key
+// is never overwritten.
+func generateCmd(fs *flag.FlagSet) func() error {
+	var kf keyFlags
+	kf.register(fs)
+
+	return func() error {
+		file, err := kf.file()
+		if err != nil {
+			return err
+		}
+
+		if _, err := os.Stat(file); err == nil {
+			return fmt.Errorf("key for account %q already exists at %s", kf.name, file)
+		}
+
+		privateKey, err := crypto.GenerateKey()
+		if err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+			return err
+		}
+
+		if err := crypto.SaveECDSA(file, privateKey); err != nil {
+			return err
+		}
+
+		fmt.Printf("key: %s\n", file)
+		fmt.Println(acc.PublicKeyToAccountID(privateKey.PublicKey))
+		return nil
+	}
+}
+
+// accountCmd prints the account id derived from the account's key.
+func accountCmd(fs *flag.FlagSet) func() error {
+	var kf keyFlags
+	kf.register(fs)
+
+	return func() error {
+		accountID, err := kf.accountID()
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(accountID)
+		return nil
+	}
+}
+
+// balanceCmd prints the balance and nonce of the account as known by the
+// node.
+func balanceCmd(fs *flag.FlagSet) func() error {
+	var kf keyFlags
+	kf.register(fs)
+	url := fs.String("u", "http://localhost:8080", "url of the node's public api")
+
+	return func() error {
+		accountID, err := kf.accountID()
+		if err != nil {
+			return err
+		}
+
+		account, err := queryAccount(*url, accountID)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("account: %s\n", accountID)
+		fmt.Printf("balance: %s\n", currency.FormatWithDenomination(account.Balance, currency.Ether))
+		fmt.Printf("nonce:   %d\n", account.Nonce)
+		return nil
+	}
+}
+
+// signCmd signs a transaction and prints it in the form the node expects
+// to receive it.
+func signCmd(fs *flag.FlagSet) func() error {
+	var kf keyFlags
+	kf.register(fs)
+	var tf txFlags
+	tf.register(fs)
+
+	return func() error {
+		if tf.nonce == 0 {
+			return errors.New("nonce is missing, use -n")
+		}
+
+		signedTx, err := tf.sign(&kf)
+		if err != nil {
+			return err
+		}
+
+		data, err := json.MarshalIndent(signedTx, "", "    ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(data))
+		return nil
+	}
+}
+
+// sendCmd signs a transaction and submits it to the node. Without a nonce
+// the next nonce for the account is worked out from the node, counting the
+// account's transactions still waiting in the node's mempool.
+func sendCmd(fs *flag.FlagSet) func() error {
+	var kf keyFlags
+	kf.register(fs)
+	var tf txFlags
+	tf.register(fs)
+	url := fs.String("u", "http://localhost:8080", "url of the node's public api")
+
+	return func() error {
+		if tf.nonce == 0 {
+			accountID, err := kf.accountID()
+			if err != nil {
+				return err
+			}
+
+			if tf.nonce, err = nextNonce(*url, accountID); err != nil {
+				return err
+			}
+		}
+
+		signedTx, err := tf.sign(&kf)
+		if err != nil {
+			return err
+		}
+
+		var resp struct {
+			Status string `json:"status"`
+		}
+		if err := send(http.MethodPost, trimURL(*url)+"/v1/tx/submit", signedTx, &resp); err != nil {
+			return err
+		}
+
+		fmt.Printf("nonce[%d]: %s\n", tf.nonce, resp.Status)
+		return nil
+	}
+}
+
+// =============================================================================
+
+// txFlags represents the flags required to construct a transaction.
+type txFlags struct {
+	chainID uint
+	txType  string
+	nonce   uint64
+	to      string
+	value   string
+	tip     string
+	data    string
+	expires uint64
+	granter string
+}
+
+// register adds the transaction flags to the flag set.
+func (tf *txFlags) register(fs *flag.FlagSet) {
+	fs.UintVar(&tf.chainID, "c", 1, "chain id of the network the transaction is for")
+	fs.StringVar(&tf.txType, "y", "", "type of the transaction, like rotate_key or grant_fee, a transfer when empty")
+	fs.Uint64Var(&tf.nonce, "n", 0, "nonce of the transaction")
+	fs.StringVar(&tf.to, "t", "", "account id receiving the value, the sender when empty for types that don't use one")
+	fs.StringVar(&tf.value, "v", "0", `value to send, like "100" or "1.5 ether"`)
+	fs.StringVar(&tf.tip, "p", "0", `tip for the miner, like "10" or "2 gwei"`)
+	fs.StringVar(&tf.data, "d", "", "data to include with the transaction")
+	fs.Uint64Var(&tf.expires, "e", 0, "last block number the transaction can be included in, 0 never expires")
+	fs.StringVar(&tf.granter, "g", "", "account id paying the gas through a fee grant")
+}
+
+// sign constructs the transaction and signs it with the account's key.
+func (tf *txFlags) sign(kf *keyFlags) (transaction.SignedTx, error) {
+	privateKey, err := kf.load()
+	if err != nil {
+		return transaction.SignedTx{}, err
+	}
+
+	fromID := acc.PublicKeyToAccountID(privateKey.PublicKey)
+
+	// Some types, like set_guardians, don't send anything to an account.
+	toID := fromID
+	if tf.to != "" || tf.txType == "" || tf.txType == transaction.TypeTransfer {
+		if toID, err = acc.ToAccountID(tf.to); err != nil {
+			return transaction.SignedTx{}, fmt.Errorf("to account: %w", err)
+		}
+	}
+
+	value, err := currency.ParseWithDenomination(tf.value)
+	if err != nil {
+		return transaction.SignedTx{}, fmt.Errorf("value: %w", err)
+	}
+
+	tip, err := currency.ParseWithDenomination(tf.tip)
+	if err != nil {
+		return transaction.SignedTx{}, fmt.Errorf("tip: %w", err)
+	}
+
+	var data []byte
+	if tf.data != "" {
+		data = []byte(tf.data)
+	}
+
+	tx, err := transaction.NewTx(uint16(tf.chainID), tf.nonce, fromID, toID, value, tip, data)
+	if err != nil {
+		return transaction.SignedTx{}, err
+	}
+	tx.Type = tf.txType
+	tx.ExpiresAt = tf.expires
+
+	if tf.granter != "" {
+		granterID, err := acc.ToAccountID(tf.granter)
+		if err != nil {
+			return transaction.SignedTx{}, fmt.Errorf("fee granter: %w", err)
+		}
+		tx.FeeGranter = granterID
+	}
+
+	return tx.Sign(privateKey)
+}
+
+// =============================================================================
+
+// account represents the part of the node's account information the
+// wallet needs.
+type account struct {
+	Balance amount.Amount `json:"balance"`
+	Nonce   uint64        `json:"nonce"`
+}
+
+// queryAccount asks the node for the account. An account the node doesn't
+// know about yet has a zero balance and nonce.
+func queryAccount(url string, accountID acc.AccountID) (account, error) {
+	var act account
+	err := send(http.MethodGet, fmt.Sprintf("%s/v1/accounts/list/%s", trimURL(url), accountID), nil, &act)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return account{}, err
+	}
+
+	return act, nil
+}
+
+// nextNonce returns the nonce for the account's next transaction. The
+// account's transactions waiting in the node's mempool have already taken
+// the nonces after the account's nonce.
+func nextNonce(url string, accountID acc.AccountID) (uint64, error) {
+	act, err := queryAccount(url, accountID)
+	if err != nil {
+		return 0, err
+	}
+
+	var pending []struct {
+		FromID acc.AccountID `json:"from"`
+		Nonce  uint64        `json:"nonce"`
+	}
+	if err := send(http.MethodGet, trimURL(url)+"/v1/tx/uncommitted/list", nil, &pending); err != nil {
+		return 0, err
+	}
+
+	nonce := act.Nonce
+	for _, tx := range pending {
+		if tx.FromID == accountID && tx.Nonce > nonce {
+			nonce = tx.Nonce
+		}
+	}
+
+	return nonce + 1, nil
+}
+
+// errNotFound is returned when the node responds with a 404.
+var errNotFound = errors.New("not found")
+
+// send performs the HTTP request and decodes the response. The node's error
+// message is returned for failed requests.
+func send(method string, url string, dataSend any, dataRecv any) error {
+	var body io.Reader
+	if dataSend != nil {
+		data, err := json.Marshal(dataSend)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil || errResp.Error == "" {
+			return fmt.Errorf("node responded with status %d", resp.StatusCode)
+		}
+		return fmt.Errorf("node responded with status %d: %s", resp.StatusCode, errResp.Error)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(dataRecv)
+}
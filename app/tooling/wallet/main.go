@@ -0,0 +1,133 @@
+// This program manages the keys for wallet accounts and signs and submits
+// transactions to a node.
+//
+//	wallet generate -a kennedy
+//	wallet account  -a kennedy
+//	wallet balance  -a kennedy
+//	wallet sign     -a kennedy -n 1 -t 0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76 -v "1.5 ether"
+//	wallet send     -a kennedy -t 0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76 -v 100 -p 10
+//	wallet send     -a kennedy -y grant_fee -t 0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76 -d '{"limit": "20"}'
+package main
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// command represents a wallet command and the flags it accepts.
+type command struct {
+	usage string
+	flags func(fs *flag.FlagSet) func() error
+}
+
+var commands = map[string]command{
+	"generate": {usage: "generate a new private key for an account", flags: generateCmd},
+	"account":  {usage: "print the account id for an account", flags: accountCmd},
+	"balance":  {usage: "print the balance and nonce of an account", flags: balanceCmd},
+	"sign":     {usage: "sign a transaction and print it", flags: signCmd},
+	"send":     {usage: "sign a transaction and submit it to a node", flags: sendCmd},
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func run(args []string) error {
+	if len(args) == 0 {
+		usage()
+		return errors.New("missing command")
+	}
+
+	cmd, exists := commands[args[0]]
+	if !exists {
+		usage()
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+
+	fs := flag.NewFlagSet(args[0], flag.ExitOnError)
+	exec := cmd.flags(fs)
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	return exec()
+}
+
+// usage prints the list of commands.
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "usage: wallet <command> [flags]")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].usage)
+	}
+}
+
+// =============================================================================
+
+// keyFlags represents the flags required to find an account's private key.
+type keyFlags struct {
+	name string
+	path string
+}
+
+// register adds the key flags to the flag set.
+func (kf *keyFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&kf.name, "a", "", "name of the account")
+	fs.StringVar(&kf.path, "k", "zblock/accounts/", "directory holding the account keys")
+}
+
+// file returns the location of the account's private key.
+func (kf *keyFlags) file() (string, error) {
+	if kf.name == "" {
+		return "", errors.New("account name is missing, use -a")
+	}
+
+	return filepath.Join(kf.path, kf.name+".ecdsa"), nil
+}
+
+// load reads the account's private key.
+func (kf *keyFlags) load() (*ecdsa.PrivateKey, error) {
+	file, err := kf.file()
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, err := crypto.LoadECDSA(file)
+	if err != nil {
+		return nil, fmt.Errorf("loading key for account %q: %w", kf.name, err)
+	}
+
+	return privateKey, nil
+}
+
+// accountID returns the account id for the account's private key.
+func (kf *keyFlags) accountID() (acc.AccountID, error) {
+	privateKey, err := kf.load()
+	if err != nil {
+		return "", err
+	}
+
+	return acc.PublicKeyToAccountID(privateKey.PublicKey), nil
+}
+
+// trimURL removes the trailing slash so paths can be appended.
+func trimURL(url string) string {
+	return strings.TrimSuffix(url, "/")
+}
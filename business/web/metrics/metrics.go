@@ -87,3 +87,9 @@ func AddPanics(ctx context.Context) {
 		v.panics.Add(1)
 	}
 }
+
+// AddBackgroundPanics increments the panics metric by 1 for a panic recovered
+// by a goroutine that isn't handling a request.
+func AddBackgroundPanics() {
+	m.panics.Add(1)
+}
@@ -3,7 +3,9 @@ package account
 import (
 	"crypto/ecdsa"
 	"errors"
+	"strconv"
 
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
@@ -11,11 +13,69 @@ import (
 type Account struct {
 	AccountID AccountID
 	Nonce     uint64
-	Balance   uint64
+	Balance   amount.Amount
+	CreatedAt uint64 // Number of the block the account was first seen in.
+	Sent      uint64 // Number of transactions sent by the account.
+	Received  uint64 // Number of transactions received by the account.
+
+	MigratedTo   AccountID // Account this account's key was rotated to.
+	MigratedFrom AccountID // Account whose key was rotated to this account.
+
+	Guardians []AccountID // Accounts that can recover this account if its key is lost.
+	Threshold uint16      // Number of guardians required to recover the account.
+	Recovery  *Recovery   // Recovery of the account that is in progress.
+
+	// FeeGrants holds the gas fees this account still pays for each grantee.
+	// The map is replaced, never modified, when a grant changes.
+	FeeGrants map[AccountID]amount.Amount `json:",omitempty"`
+}
+
+// Recovery represents a guardian approved recovery of an account to a new key.
+type Recovery struct {
+	NewID     AccountID
+	Approvals []AccountID
+	StartedAt uint64
+}
+
+// IsGuardian checks if the specified account is a guardian of this account.
+func (a Account) IsGuardian(accountID AccountID) bool {
+	for _, guardian := range a.Guardians {
+		if guardian == accountID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FeeGrant returns the gas fees this account still pays for the grantee.
+func (a Account) FeeGrant(grantee AccountID) amount.Amount {
+	return a.FeeGrants[grantee]
+}
+
+// SetFeeGrant sets the gas fees this account pays for the grantee. A limit
+// of zero removes the grant. The grants are copied so other copies of the
+// account are not affected.
+func (a *Account) SetFeeGrant(grantee AccountID, limit amount.Amount) {
+	grants := make(map[AccountID]amount.Amount, len(a.FeeGrants)+1)
+	for accountID, remaining := range a.FeeGrants {
+		grants[accountID] = remaining
+	}
+
+	if limit.IsZero() {
+		delete(grants, grantee)
+	} else {
+		grants[grantee] = limit
+	}
+
+	if len(grants) == 0 {
+		grants = nil
+	}
+	a.FeeGrants = grants
 }
 
 // newAccount constructs a new account value for use.
-func New(accountID AccountID, balance uint64) Account {
+func New(accountID AccountID, balance amount.Amount) Account {
 	return Account{
 		AccountID: accountID,
 		Balance:   balance,
@@ -57,6 +117,27 @@ func (a AccountID) IsAccountID() bool {
 	return len(a) == 2*addressLength && isHex(a)
 }
 
+// Shard returns the shard the account belongs to when the accounts are
+// partitioned into the specified number of shards. Accounts are partitioned
+// by the first two bytes of the address so each shard owns a contiguous
+// range of address prefixes.
+func (a AccountID) Shard(shards uint16) uint16 {
+	if shards <= 1 || !a.IsAccountID() {
+		return 0
+	}
+
+	if has0xPrefix(a) {
+		a = a[2:]
+	}
+
+	prefix, err := strconv.ParseUint(string(a[:4]), 16, 16)
+	if err != nil {
+		return 0
+	}
+
+	return uint16(prefix * uint64(shards) >> 16)
+}
+
 // =============================================================================
 
 // has0xPrefix validates the account starts with a 0x.
@@ -0,0 +1,56 @@
+package account_test
+
+import (
+	"testing"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+)
+
+func Test_Shard(t *testing.T) {
+	type table struct {
+		testCaseID int
+		accountID  acc.AccountID
+		shards     uint16
+		expected   uint16
+	}
+
+	tt := []table{
+		{testCaseID: 0, accountID: "0xFef311483Cc040e1A89fb9bb469eeB8A70935EF8", shards: 0, expected: 0},
+		{testCaseID: 1, accountID: "0xFef311483Cc040e1A89fb9bb469eeB8A70935EF8", shards: 1, expected: 0},
+		{testCaseID: 2, accountID: "0xFef311483Cc040e1A89fb9bb469eeB8A70935EF8", shards: 2, expected: 1},
+		{testCaseID: 3, accountID: "0x0000000000000000000000000000000000000000", shards: 16, expected: 0},
+		{testCaseID: 4, accountID: "0xffff000000000000000000000000000000000000", shards: 16, expected: 15},
+		{testCaseID: 5, accountID: "0x8000000000000000000000000000000000000000", shards: 4, expected: 2},
+		{testCaseID: 6, accountID: "0x1234", shards: 4, expected: 0},
+	}
+
+	for _, tst := range tt {
+		if got := tst.accountID.Shard(tst.shards); got != tst.expected {
+			t.Errorf("[case:%d] error: expected shard %d got %d", tst.testCaseID, tst.expected, got)
+		}
+	}
+}
+
+func Test_SetFeeGrant(t *testing.T) {
+	const grantee = acc.AccountID("0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76")
+
+	a := acc.New("0xFef311483Cc040e1A89fb9bb469eeB8A70935EF8", amount.New(100))
+	a.SetFeeGrant(grantee, amount.New(10))
+
+	// Changing the grants of a copy leaves the original alone.
+	cpy := a
+	cpy.SetFeeGrant(grantee, amount.New(3))
+
+	if got := a.FeeGrant(grantee); got.Cmp(amount.New(10)) != 0 {
+		t.Errorf("error: expected the original grant of 10 got %s", got)
+	}
+	if got := cpy.FeeGrant(grantee); got.Cmp(amount.New(3)) != 0 {
+		t.Errorf("error: expected the copy's grant of 3 got %s", got)
+	}
+
+	a.SetFeeGrant(grantee, amount.Amount{})
+	if a.FeeGrants != nil || !a.FeeGrant(grantee).IsZero() {
+		t.Errorf("error: expected a limit of zero to remove the grant got %v", a.FeeGrants)
+	}
+}
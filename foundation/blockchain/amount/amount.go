@@ -0,0 +1,173 @@
+// Package amount provides support for the 256-bit unsigned values used for
+// balances, values and fees so large supplies can't overflow.
+package amount
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/holiman/uint256"
+)
+
+// Set of errors produced by amount arithmetic.
+var (
+	ErrOverflow  = errors.New("amount overflow")
+	ErrUnderflow = errors.New("amount underflow")
+)
+
+// Amount represents a 256-bit unsigned value. The zero value is an amount of
+// zero and amounts are safe to copy. Amounts are encoded in JSON as a
+// hex-encoded string.
+type Amount struct {
+	v uint256.Int
+}
+
+// New constructs an amount from the specified value.
+func New(value uint64) Amount {
+	var a Amount
+	a.v.SetUint64(value)
+	return a
+}
+
+// Parse converts a hex-encoded string with a 0x prefix or a decimal string
+// into an amount.
+func Parse(s string) (Amount, error) {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		v, err := uint256.FromHex("0x" + s[2:])
+		if err != nil {
+			return Amount{}, fmt.Errorf("invalid amount %q: %w", s, err)
+		}
+		return Amount{v: *v}, nil
+	}
+
+	b, ok := new(big.Int).SetString(s, 10)
+	if !ok || b.Sign() < 0 {
+		return Amount{}, fmt.Errorf("invalid amount %q", s)
+	}
+
+	v, overflow := uint256.FromBig(b)
+	if overflow {
+		return Amount{}, ErrOverflow
+	}
+
+	return Amount{v: *v}, nil
+}
+
+// =============================================================================
+
+// Add returns the sum of the two amounts.
+func (a Amount) Add(b Amount) (Amount, error) {
+	var r Amount
+	if _, overflow := r.v.AddOverflow(&a.v, &b.v); overflow {
+		return Amount{}, ErrOverflow
+	}
+	return r, nil
+}
+
+// Sub returns the difference of the two amounts.
+func (a Amount) Sub(b Amount) (Amount, error) {
+	var r Amount
+	if _, underflow := r.v.SubOverflow(&a.v, &b.v); underflow {
+		return Amount{}, ErrUnderflow
+	}
+	return r, nil
+}
+
+// Mul returns the product of the two amounts.
+func (a Amount) Mul(b Amount) (Amount, error) {
+	var r Amount
+	if _, overflow := r.v.MulOverflow(&a.v, &b.v); overflow {
+		return Amount{}, ErrOverflow
+	}
+	return r, nil
+}
+
+// MulUint64 returns the product of the amount and the specified value.
+func (a Amount) MulUint64(n uint64) (Amount, error) {
+	return a.Mul(New(n))
+}
+
+// Cmp compares the two amounts and returns -1 if a < b, 0 if a == b and
+// +1 if a > b.
+func (a Amount) Cmp(b Amount) int {
+	return a.v.Cmp(&b.v)
+}
+
+// LessThan checks if the amount is less than the specified amount.
+func (a Amount) LessThan(b Amount) bool {
+	return a.v.Lt(&b.v)
+}
+
+// Min returns the smaller of the two amounts.
+func (a Amount) Min(b Amount) Amount {
+	if b.v.Lt(&a.v) {
+		return b
+	}
+	return a
+}
+
+// IsZero checks if the amount is zero.
+func (a Amount) IsZero() bool {
+	return a.v.IsZero()
+}
+
+// BitLen returns the number of bits required to represent the amount.
+func (a Amount) BitLen() int {
+	return a.v.BitLen()
+}
+
+// Big returns the amount as a big integer.
+func (a Amount) Big() *big.Int {
+	return a.v.ToBig()
+}
+
+// Bytes32 returns the amount as a 32-byte big-endian value.
+func (a Amount) Bytes32() [32]byte {
+	return a.v.Bytes32()
+}
+
+// Hex returns the amount as a hex-encoded string with a 0x prefix.
+func (a Amount) Hex() string {
+	return a.v.Hex()
+}
+
+// String implements the Stringer interface and returns the amount in decimal.
+func (a Amount) String() string {
+	return a.v.ToBig().String()
+}
+
+// =============================================================================
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (a Amount) MarshalText() ([]byte, error) {
+	return []byte(a.Hex()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface. Both hex
+// and decimal strings are accepted.
+func (a *Amount) UnmarshalText(text []byte) error {
+	v, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+
+	*a = v
+	return nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Along with strings,
+// JSON numbers are accepted so hand written files like the genesis file can
+// keep using plain numbers.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	if len(data) >= 2 && data[0] == '"' && data[len(data)-1] == '"' {
+		data = data[1 : len(data)-1]
+	}
+
+	return a.UnmarshalText(data)
+}
@@ -0,0 +1,184 @@
+package amount_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+)
+
+// max is the largest value an amount can hold.
+var max = "0x" + strings.Repeat("f", 64)
+
+func mustParse(s string) amount.Amount {
+	a, err := amount.Parse(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return a
+}
+
+// =============================================================================
+
+func Test_Parse(t *testing.T) {
+	type table struct {
+		testCaseID int
+		value      string
+		expected   string
+		err        error
+		success    bool
+	}
+
+	tt := []table{
+		{testCaseID: 0, value: "0", expected: "0", success: true},
+		{testCaseID: 1, value: "1500", expected: "1500", success: true},
+		{testCaseID: 2, value: "0x5dc", expected: "1500", success: true},
+		{testCaseID: 3, value: "0X5DC", expected: "1500", success: true},
+		{testCaseID: 4, value: "18446744073709551616", expected: "18446744073709551616", success: true},
+		{testCaseID: 5, value: max, expected: "115792089237316195423570985008687907853269984665640564039457584007913129639935", success: true},
+		{testCaseID: 6, value: "115792089237316195423570985008687907853269984665640564039457584007913129639936", err: amount.ErrOverflow},
+		{testCaseID: 7, value: "0x1" + strings.Repeat("0", 64)},
+		{testCaseID: 8, value: "-1"},
+		{testCaseID: 9, value: "1.5"},
+		{testCaseID: 10, value: ""},
+		{testCaseID: 11, value: "0xzz"},
+	}
+
+	for _, tst := range tt {
+		a, err := amount.Parse(tst.value)
+
+		if !tst.success {
+			if err == nil {
+				t.Errorf("[case:%d] error: expected %q to fail got %s", tst.testCaseID, tst.value, a)
+			}
+			if tst.err != nil && !errors.Is(err, tst.err) {
+				t.Errorf("[case:%d] error: expected error %v got %v", tst.testCaseID, tst.err, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+		if a.String() != tst.expected {
+			t.Errorf("[case:%d] error: expected %s got %s", tst.testCaseID, tst.expected, a)
+		}
+	}
+}
+
+func Test_Arithmetic(t *testing.T) {
+	type table struct {
+		testCaseID int
+		op         func() (amount.Amount, error)
+		expected   string
+		err        error
+	}
+
+	tt := []table{
+		{
+			testCaseID: 0,
+			op:         func() (amount.Amount, error) { return amount.New(2).Add(amount.New(3)) },
+			expected:   "5",
+		},
+		{
+			testCaseID: 1,
+			op:         func() (amount.Amount, error) { return mustParse(max).Add(amount.New(1)) },
+			err:        amount.ErrOverflow,
+		},
+		{
+			testCaseID: 2,
+			op:         func() (amount.Amount, error) { return amount.New(5).Sub(amount.New(3)) },
+			expected:   "2",
+		},
+		{
+			testCaseID: 3,
+			op:         func() (amount.Amount, error) { return amount.New(3).Sub(amount.New(5)) },
+			err:        amount.ErrUnderflow,
+		},
+		{
+			testCaseID: 4,
+			op:         func() (amount.Amount, error) { return amount.New(1 << 63).MulUint64(4) },
+			expected:   "36893488147419103232",
+		},
+		{
+			testCaseID: 5,
+			op:         func() (amount.Amount, error) { return mustParse(max).MulUint64(2) },
+			err:        amount.ErrOverflow,
+		},
+		{
+			testCaseID: 6,
+			op: func() (amount.Amount, error) {
+				return mustParse("0x1" + strings.Repeat("0", 32)).Mul(mustParse("0x1" + strings.Repeat("0", 32)))
+			},
+			err: amount.ErrOverflow,
+		},
+	}
+
+	for _, tst := range tt {
+		a, err := tst.op()
+
+		if tst.err != nil {
+			if !errors.Is(err, tst.err) {
+				t.Errorf("[case:%d] error: expected error %v got %v", tst.testCaseID, tst.err, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+		if a.String() != tst.expected {
+			t.Errorf("[case:%d] error: expected %s got %s", tst.testCaseID, tst.expected, a)
+		}
+	}
+}
+
+func Test_JSON(t *testing.T) {
+	type table struct {
+		testCaseID int
+		data       string
+		expected   string
+	}
+
+	tt := []table{
+		{testCaseID: 0, data: `{"value":1500}`, expected: "1500"},
+		{testCaseID: 1, data: `{"value":"1500"}`, expected: "1500"},
+		{testCaseID: 2, data: `{"value":"0x5dc"}`, expected: "1500"},
+		{testCaseID: 3, data: `{"value":null}`, expected: "0"},
+	}
+
+	for _, tst := range tt {
+		var v struct {
+			Value amount.Amount `json:"value"`
+		}
+		if err := json.Unmarshal([]byte(tst.data), &v); err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+		if v.Value.String() != tst.expected {
+			t.Errorf("[case:%d] error: expected %s got %s", tst.testCaseID, tst.expected, v.Value)
+		}
+
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+
+		var back struct {
+			Value amount.Amount `json:"value"`
+		}
+		if err := json.Unmarshal(data, &back); err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+		if back.Value.Cmp(v.Value) != 0 {
+			t.Errorf("[case:%d] error: expected %s after a round trip got %s", tst.testCaseID, v.Value, back.Value)
+		}
+	}
+}
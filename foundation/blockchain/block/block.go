@@ -6,6 +6,7 @@ import (
 	"time"
 
 	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
 	"github.com/dudakovict/blockchain/foundation/blockchain/merkle"
 	"github.com/dudakovict/blockchain/foundation/blockchain/signature"
 	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
@@ -15,6 +16,10 @@ import (
 // is two or more blocks ahead of ours.
 var ErrChainForked = errors.New("blockchain forked, start resync")
 
+// GasLimitBoundDivisor bounds how much the gas limit can change from one block
+// to the next. Each block can move the limit by at most 1/1024 of its parent's.
+const GasLimitBoundDivisor = 1024
+
 // BlockHeader represents common information required for each block.
 type BlockHeader struct {
 	Number        uint64        `json:"number"`
@@ -22,10 +27,13 @@ type BlockHeader struct {
 	TimeStamp     uint64        `json:"timestamp"`
 	BeneficiaryID acc.AccountID `json:"beneficiary"`
 	Difficulty    uint16        `json:"difficulty"`
-	MiningReward  uint64        `json:"mining_reward"`
-	StateRoot     string        `json:"state_root"` // Ethereum: Represents a hash of the accounts and their balances.
+	MiningReward  amount.Amount `json:"mining_reward"`
+	GasLimit      uint64        `json:"gas_limit,omitempty"`   // Ethereum: The maximum number of gas units the transactions can use.
+	StateRoot     string        `json:"state_root"`            // Ethereum: Represents a hash of the accounts and their balances.
+	ShardRoots    []string      `json:"shard_roots,omitempty"` // Experimental: Represents a hash of the accounts in each shard.
 	TransRoot     string        `json:"trans_root"`
 	Nonce         uint64        `json:"nonce"`
+	Signature     string        `json:"signature,omitempty"` // Proof of authority: The authority's signature over the header.
 }
 
 // Block represents a group of transactions batched together.
@@ -56,30 +64,103 @@ func (b Block) Hash() string {
 	return signature.Hash(b.Header)
 }
 
-func (b Block) ValidateBlock(previousBlock Block, stateRoot string) error {
+// NextGasLimit calculates the gas limit for the block after the parent. The
+// limit moves towards the target signaled by the miner, bounded by the
+// maximum change allowed from the parent's limit. A chain without a limit
+// stays without one.
+func NextGasLimit(parentGasLimit uint64, target uint64) uint64 {
+	delta := parentGasLimit / GasLimitBoundDivisor
+
+	switch {
+	case target > parentGasLimit:
+		if target-parentGasLimit > delta {
+			return parentGasLimit + delta
+		}
+
+	case target < parentGasLimit:
+		if parentGasLimit-target > delta {
+			return parentGasLimit - delta
+		}
+	}
+
+	return target
+}
+
+// GasUsed returns the total gas units used by the transactions in the block.
+func (b Block) GasUsed() uint64 {
+	if b.MerkleTree == nil {
+		return 0
+	}
+
+	var gasUsed uint64
+	for _, tx := range b.MerkleTree.Values() {
+		gasUsed += tx.GasUnits
+	}
+
+	return gasUsed
+}
+
+// ValidateBlock checks the block extends the previous block and was mined
+// at the difficulty the chain expects for it. The parent's gas limit is the
+// genesis gas limit for the first block.
+func (b Block) ValidateBlock(previousBlock Block, difficulty uint16, parentGasLimit uint64) error {
 	nextNumber := previousBlock.Header.Number + 1
 	if b.Header.Number >= (nextNumber + 2) {
 		return ErrChainForked
 	}
 
-	if b.Header.Difficulty < previousBlock.Header.Difficulty {
-		return fmt.Errorf("block difficulty is less than previous block difficulty, parent %d, block %d", previousBlock.Header.Difficulty, b.Header.Difficulty)
+	if err := ValidateHeader(b.Header, previousBlock.Header, difficulty, parentGasLimit); err != nil {
+		return err
+	}
+
+	if b.MerkleTree != nil {
+		for _, tx := range b.MerkleTree.Values() {
+			if tx.Expired(b.Header.Number) {
+				return fmt.Errorf("block includes an expired transaction, from %s, nonce %d, expired at block %d", tx.FromID, tx.Nonce, tx.ExpiresAt)
+			}
+		}
 	}
 
-	if b.Header.Number != nextNumber {
-		return fmt.Errorf("this block is not the next number, got %d, exp %d", b.Header.Number, nextNumber)
+	if b.Header.GasLimit > 0 {
+		if gasUsed := b.GasUsed(); gasUsed > b.Header.GasLimit {
+			return fmt.Errorf("block gas used exceeds gas limit, used %d, limit %d", gasUsed, b.Header.GasLimit)
+		}
 	}
 
-	if b.Header.PrevBlockHash != previousBlock.Hash() {
-		return fmt.Errorf("parent block hash doesn't match our known parent, got %s, exp %s", b.Header.PrevBlockHash, previousBlock.Hash())
+	return nil
+}
+
+// ValidateHeader checks the header extends the parent's header. It must
+// carry the next number and the parent's hash, be mined at the expected
+// difficulty, keep the gas limit within the bounds of the parent's and not
+// be timestamped before the parent. These checks need no account state, so
+// they are shared by the database and by verifiers working from headers
+// alone. The proof is checked separately since it depends on the consensus.
+func ValidateHeader(header BlockHeader, parent BlockHeader, difficulty uint16, parentGasLimit uint64) error {
+	if header.Difficulty != difficulty {
+		return fmt.Errorf("block difficulty is not the expected difficulty, got %d, exp %d", header.Difficulty, difficulty)
 	}
 
-	if previousBlock.Header.TimeStamp > 0 {
-		parentTime := time.Unix(int64(previousBlock.Header.TimeStamp), 0)
-		blockTime := time.Unix(int64(b.Header.TimeStamp), 0)
-		if blockTime.Before(parentTime) {
-			return fmt.Errorf("block timestamp is before parent block, parent %s, block %s", parentTime, blockTime)
-		}
+	if nextNumber := parent.Number + 1; header.Number != nextNumber {
+		return fmt.Errorf("this block is not the next number, got %d, exp %d", header.Number, nextNumber)
+	}
+
+	if parentHash := (Block{Header: parent}).Hash(); header.PrevBlockHash != parentHash {
+		return fmt.Errorf("parent block hash doesn't match our known parent, got %s, exp %s", header.PrevBlockHash, parentHash)
+	}
+
+	if parentGasLimit > 0 && header.GasLimit == 0 {
+		return fmt.Errorf("block gas limit is missing, parent %d", parentGasLimit)
+	}
+
+	if NextGasLimit(parentGasLimit, header.GasLimit) != header.GasLimit {
+		return fmt.Errorf("block gas limit changed more than 1/%d of parent, parent %d, block %d", GasLimitBoundDivisor, parentGasLimit, header.GasLimit)
+	}
+
+	if parent.TimeStamp > 0 && header.TimeStamp < parent.TimeStamp {
+		parentTime := time.UnixMilli(int64(parent.TimeStamp))
+		blockTime := time.UnixMilli(int64(header.TimeStamp))
+		return fmt.Errorf("block timestamp is before parent block, parent %s, block %s", parentTime, blockTime)
 	}
 
 	return nil
@@ -0,0 +1,263 @@
+package block_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/signature"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+)
+
+func Test_BuildBlock(t *testing.T) {
+	trans := []transaction.BlockTx{
+		signedTx(t, "a", 1, 0, 5),
+		signedTx(t, "b", 1, 0, 10),
+		signedTx(t, "b", 2, 0, 1),
+		signedTx(t, "a", 2, 0, 3),
+		signedTx(t, "c", 1, 0, 1),
+	}
+
+	type table struct {
+		testCaseID int
+		policy     block.BuildPolicy
+		trans      []transaction.BlockTx
+		expected   int
+		gasUsed    uint64
+		err        error
+	}
+
+	tt := []table{
+		{testCaseID: 0, trans: trans, expected: 5, gasUsed: 20},
+
+		// Account b's first transaction doesn't fit, so its second is
+		// skipped to keep the nonces in sequence.
+		{testCaseID: 1, policy: block.BuildPolicy{GasLimit: 9}, trans: trans, expected: 3, gasUsed: 9},
+		{testCaseID: 2, policy: block.BuildPolicy{MaxTrans: 2}, trans: trans, expected: 2, gasUsed: 15},
+		{testCaseID: 3, policy: block.BuildPolicy{GasLimit: 5}, trans: trans[1:3], err: block.ErrNoTransactionsFit},
+		{testCaseID: 4, trans: nil, err: block.ErrNoTransactionsFit},
+	}
+
+	for _, tst := range tt {
+		tst.policy.BeneficiaryID = toID
+		tst.policy.TimeStamp = 5
+
+		b, err := block.BuildBlock(block.Block{}, tst.trans, tst.policy)
+
+		if tst.err != nil {
+			if !errors.Is(err, tst.err) {
+				t.Errorf("[case:%d] error: expected error %v got %v", tst.testCaseID, tst.err, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+
+		if n := len(b.MerkleTree.Values()); n != tst.expected {
+			t.Errorf("[case:%d] error: expected %d transactions got %d", tst.testCaseID, tst.expected, n)
+		}
+		if b.GasUsed() != tst.gasUsed {
+			t.Errorf("[case:%d] error: expected %d gas used got %d", tst.testCaseID, tst.gasUsed, b.GasUsed())
+		}
+		if b.Header.Number != 1 || b.Header.PrevBlockHash != signature.ZeroHash {
+			t.Errorf("[case:%d] error: expected block 1 after the genesis got %d after %s", tst.testCaseID, b.Header.Number, b.Header.PrevBlockHash)
+		}
+
+		// The same inputs always produce the same block.
+		again, err := block.BuildBlock(block.Block{}, tst.trans, tst.policy)
+		if err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+		if again.Hash() != b.Hash() {
+			t.Errorf("[case:%d] error: expected the same block for the same inputs", tst.testCaseID)
+		}
+	}
+}
+
+func Test_NextGasLimit(t *testing.T) {
+	type table struct {
+		testCaseID int
+		parent     uint64
+		target     uint64
+		expected   uint64
+	}
+
+	tt := []table{
+		{testCaseID: 0, parent: 2048, target: 2048, expected: 2048},
+		{testCaseID: 1, parent: 2048, target: 5000, expected: 2050},
+		{testCaseID: 2, parent: 2048, target: 2049, expected: 2049},
+		{testCaseID: 3, parent: 2048, target: 1, expected: 2046},
+		{testCaseID: 4, parent: 2048, target: 2047, expected: 2047},
+		{testCaseID: 5, parent: 0, target: 0, expected: 0},
+		{testCaseID: 6, parent: 0, target: 100, expected: 0},
+		{testCaseID: 7, parent: 1000, target: 2000, expected: 1000},
+	}
+
+	for _, tst := range tt {
+		if got := block.NextGasLimit(tst.parent, tst.target); got != tst.expected {
+			t.Errorf("[case:%d] error: expected gas limit %d got %d", tst.testCaseID, tst.expected, got)
+		}
+	}
+}
+
+func Test_ValidateBlock(t *testing.T) {
+	policy := block.BuildPolicy{BeneficiaryID: toID, TimeStamp: 1000, Difficulty: 2, GasLimit: 100}
+
+	parent, err := block.BuildBlock(block.Block{}, []transaction.BlockTx{signedTx(t, "a", 1, 0, 1)}, policy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	next := func(change func(p *block.BuildPolicy), trans ...transaction.BlockTx) block.Block {
+		p := policy
+		p.TimeStamp = 2000
+		change(&p)
+
+		if len(trans) == 0 {
+			trans = []transaction.BlockTx{signedTx(t, "a", 2, 0, 1)}
+		}
+
+		b, err := block.BuildBlock(parent, trans, p)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return b
+	}
+
+	expired := signedTx(t, "b", 1, 0, 1)
+	expired.ExpiresAt = 1
+	expired.SignedTx, err = expired.Tx.Sign(keys["b"])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	type table struct {
+		testCaseID int
+		block      block.Block
+		difficulty uint16
+		expected   string
+		err        error
+	}
+
+	tt := []table{
+		{testCaseID: 0, block: next(func(p *block.BuildPolicy) {}), difficulty: 2},
+		{testCaseID: 1, block: next(func(p *block.BuildPolicy) {}), difficulty: 3, expected: "difficulty"},
+		{testCaseID: 2, block: next(func(p *block.BuildPolicy) { p.TimeStamp = 999 }), difficulty: 2, expected: "timestamp"},
+		{testCaseID: 3, block: next(func(p *block.BuildPolicy) { p.GasLimit = 0 }), difficulty: 2, expected: "gas limit"},
+		{testCaseID: 4, block: next(func(p *block.BuildPolicy) { p.GasLimit = 102 }), difficulty: 2, expected: "gas limit"},
+		{testCaseID: 5, block: next(func(p *block.BuildPolicy) {}, expired), difficulty: 2, expected: "expired"},
+	}
+
+	overLimit := next(func(p *block.BuildPolicy) { p.GasLimit = 0 }, signedTx(t, "a", 2, 0, 101))
+	overLimit.Header.GasLimit = 100
+	tt = append(tt, table{testCaseID: 6, block: overLimit, difficulty: 2, expected: "gas used"})
+
+	wrongParent := next(func(p *block.BuildPolicy) {})
+	wrongParent.Header.PrevBlockHash = signature.ZeroHash
+	tt = append(tt, table{testCaseID: 7, block: wrongParent, difficulty: 2, expected: "parent block hash"})
+
+	wrongNumber := next(func(p *block.BuildPolicy) {})
+	wrongNumber.Header.Number = 1
+	tt = append(tt, table{testCaseID: 8, block: wrongNumber, difficulty: 2, expected: "next number"})
+
+	ahead := next(func(p *block.BuildPolicy) {})
+	ahead.Header.Number = 4
+	tt = append(tt, table{testCaseID: 9, block: ahead, difficulty: 2, err: block.ErrChainForked})
+
+	for _, tst := range tt {
+		err := tst.block.ValidateBlock(parent, tst.difficulty, parent.Header.GasLimit)
+
+		switch {
+		case tst.err != nil:
+			if !errors.Is(err, tst.err) {
+				t.Errorf("[case:%d] error: expected error %v got %v", tst.testCaseID, tst.err, err)
+			}
+
+		case tst.expected == "":
+			if err != nil {
+				t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			}
+
+		case err == nil:
+			t.Errorf("[case:%d] error: expected the block to be rejected", tst.testCaseID)
+
+		case !strings.Contains(err.Error(), tst.expected):
+			t.Errorf("[case:%d] error: expected an error about %q got %v", tst.testCaseID, tst.expected, err)
+		}
+	}
+}
+
+func Test_NewRewards(t *testing.T) {
+	b := block.Block{Header: block.BlockHeader{Number: 1, MiningReward: amount.New(700)}}
+
+	type table struct {
+		testCaseID int
+		fees       []block.TxFee
+		expected   block.Rewards
+		success    bool
+	}
+
+	tt := []table{
+		{
+			testCaseID: 0,
+			expected:   block.Rewards{MiningReward: amount.New(700), Total: amount.New(700)},
+			success:    true,
+		},
+		{
+			testCaseID: 1,
+			fees: []block.TxFee{
+				{GasFee: amount.New(10), Tip: amount.New(3), Burned: amount.New(4)},
+				{GasFee: amount.New(20), Tip: amount.New(0), Burned: amount.New(8)},
+				{GasFee: amount.New(5), Error: "transaction invalid, insufficient funds"},
+			},
+			expected: block.Rewards{
+				MiningReward: amount.New(700),
+				TotalGasFees: amount.New(35),
+				TotalTips:    amount.New(3),
+				TotalBurned:  amount.New(12),
+				Total:        amount.New(726),
+			},
+			success: true,
+		},
+		{
+			testCaseID: 2,
+			fees: []block.TxFee{
+				{GasFee: amount.New(1), Burned: amount.New(2)},
+			},
+		},
+	}
+
+	for _, tst := range tt {
+		rewards, err := block.NewRewards(b, tst.fees)
+
+		if !tst.success {
+			if err == nil {
+				t.Errorf("[case:%d] error: expected burning more than the gas fees to fail", tst.testCaseID)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+
+		got := []amount.Amount{rewards.MiningReward, rewards.TotalGasFees, rewards.TotalTips, rewards.TotalBurned, rewards.Total}
+		exp := []amount.Amount{tst.expected.MiningReward, tst.expected.TotalGasFees, tst.expected.TotalTips, tst.expected.TotalBurned, tst.expected.Total}
+		for i := range got {
+			if got[i].Cmp(exp[i]) != 0 {
+				t.Errorf("[case:%d] error: expected %s got %s in field %d", tst.testCaseID, exp[i], got[i], i)
+			}
+		}
+
+		if len(rewards.TxFees) != len(tst.fees) {
+			t.Errorf("[case:%d] error: expected %d fees got %d", tst.testCaseID, len(tst.fees), len(rewards.TxFees))
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package block
+
+import (
+	"errors"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+	"github.com/dudakovict/blockchain/foundation/blockchain/merkle"
+	"github.com/dudakovict/blockchain/foundation/blockchain/signature"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+)
+
+// ErrNoTransactionsFit is returned from BuildBlock when none of the
+// transactions fit in the block.
+var ErrNoTransactionsFit = errors.New("no transactions fit in the block")
+
+// BuildPolicy represents the rules and proposer supplied values used to
+// build a candidate block.
+type BuildPolicy struct {
+	Ordering OrderingPolicy // Order the included transactions must be in.
+	GasLimit uint64         // Gas units the transactions can use, 0 is no limit.
+	MaxTrans int            // Number of transactions the block can hold, 0 is no limit.
+
+	// These values are provided by the proposer and copied into the header.
+	BeneficiaryID acc.AccountID
+	TimeStamp     uint64
+	Difficulty    uint16
+	MiningReward  amount.Amount
+}
+
+// BuildBlock constructs the candidate block that extends the parent. The
+// transactions are taken in the order provided until the gas limit or the
+// transaction limit is reached, then placed in the order the policy requires.
+// A transaction that doesn't fit is skipped along with the rest of the
+// sender's transactions so the nonces stay in sequence. The same inputs
+// always produce the same block, so the result doesn't depend on how the
+// block is sealed afterwards. The state roots depend on executing the block
+// against the accounts, so they are left for the database to fill in.
+func BuildBlock(parent Block, trans []transaction.BlockTx, policy BuildPolicy) (Block, error) {
+
+	// When building the first block, the previous block's hash will be zero.
+	prevBlockHash := signature.ZeroHash
+	if parent.Header.Number > 0 {
+		prevBlockHash = parent.Hash()
+	}
+
+	var included []transaction.BlockTx
+	var gasUsed uint64
+	skipped := make(map[acc.AccountID]bool)
+
+	for _, tx := range trans {
+		if policy.MaxTrans > 0 && len(included) == policy.MaxTrans {
+			break
+		}
+
+		if skipped[tx.FromID] {
+			continue
+		}
+
+		if policy.GasLimit > 0 && gasUsed+tx.GasUnits > policy.GasLimit {
+			skipped[tx.FromID] = true
+			continue
+		}
+
+		included = append(included, tx)
+		gasUsed += tx.GasUnits
+	}
+
+	if len(included) == 0 {
+		return Block{}, ErrNoTransactionsFit
+	}
+
+	// Place the transactions in the order required by the chain's policy.
+	included, err := OrderTransactions(policy.Ordering, prevBlockHash, included)
+	if err != nil {
+		return Block{}, err
+	}
+
+	// Construct a merkle tree from the transactions for this block. The root
+	// of this tree will be part of the block header.
+	tree, err := merkle.NewTree(included)
+	if err != nil {
+		return Block{}, err
+	}
+
+	b := Block{
+		Header: BlockHeader{
+			Number:        parent.Header.Number + 1,
+			PrevBlockHash: prevBlockHash,
+			TimeStamp:     policy.TimeStamp,
+			BeneficiaryID: policy.BeneficiaryID,
+			Difficulty:    policy.Difficulty,
+			MiningReward:  policy.MiningReward,
+			GasLimit:      policy.GasLimit,
+			TransRoot:     tree.RootHex(),
+			Nonce:         0,
+		},
+		MerkleTree: tree,
+	}
+
+	return b, nil
+}
@@ -0,0 +1,73 @@
+package block
+
+import (
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+)
+
+// TxFee represents what applying a transaction in the block charged. A
+// failed transaction is still charged the gas fee, or what was left of the
+// payer's balance, but not the tip.
+type TxFee struct {
+	TxHash string        `json:"tx_hash"`
+	FromID acc.AccountID `json:"from"`
+	Nonce  uint64        `json:"nonce"`
+	PaidBy acc.AccountID `json:"paid_by,omitempty"` // Account charged the gas fee, the fee granter when a grant covered it.
+	GasFee amount.Amount `json:"gas_fee"`
+	Tip    amount.Amount `json:"tip"`
+	Burned amount.Amount `json:"burned"` // Part of the gas fee taken out of circulation instead of paid to the beneficiary.
+	Error  string        `json:"error,omitempty"`
+}
+
+// Rewards represents the breakdown of what the beneficiary earns for a block.
+type Rewards struct {
+	MiningReward amount.Amount `json:"mining_reward"`
+	TotalGasFees amount.Amount `json:"total_gas_fees"`
+	TotalTips    amount.Amount `json:"total_tips"`
+	TotalBurned  amount.Amount `json:"total_burned"`
+	Total        amount.Amount `json:"total"`
+	TxFees       []TxFee       `json:"tx_fees"`
+}
+
+// NewRewards calculates the breakdown of the mining reward and the fees
+// charged by each transaction in the block. The fees are recorded when the
+// block is applied, since what a transaction is charged depends on the
+// accounts at the time. The beneficiary earns the gas fees that weren't
+// burned.
+func NewRewards(b Block, fees []TxFee) (Rewards, error) {
+	rewards := Rewards{
+		MiningReward: b.Header.MiningReward,
+		TxFees:       fees,
+	}
+
+	var err error
+	for _, fee := range fees {
+		if rewards.TotalGasFees, err = rewards.TotalGasFees.Add(fee.GasFee); err != nil {
+			return Rewards{}, err
+		}
+
+		if rewards.TotalTips, err = rewards.TotalTips.Add(fee.Tip); err != nil {
+			return Rewards{}, err
+		}
+
+		if rewards.TotalBurned, err = rewards.TotalBurned.Add(fee.Burned); err != nil {
+			return Rewards{}, err
+		}
+	}
+
+	earned, err := rewards.TotalGasFees.Sub(rewards.TotalBurned)
+	if err != nil {
+		return Rewards{}, err
+	}
+
+	total, err := rewards.MiningReward.Add(earned)
+	if err != nil {
+		return Rewards{}, err
+	}
+
+	if rewards.Total, err = total.Add(rewards.TotalTips); err != nil {
+		return Rewards{}, err
+	}
+
+	return rewards, nil
+}
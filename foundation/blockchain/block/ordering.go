@@ -0,0 +1,140 @@
+package block
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+)
+
+// OrderingPolicy represents the rule used to order transactions in a block.
+type OrderingPolicy string
+
+// Set of ordering policies a chain can opt in to.
+const (
+	// OrderingNone lets the miner choose the order of the transactions.
+	OrderingNone OrderingPolicy = ""
+
+	// OrderingFeeShuffle groups transactions into power of two tip buckets,
+	// highest first, and shuffles each bucket using the parent block hash.
+	OrderingFeeShuffle OrderingPolicy = "fee_shuffle"
+)
+
+// ToOrderingPolicy converts a string from configuration into an ordering
+// policy and validates the policy is supported.
+func ToOrderingPolicy(policy string) (OrderingPolicy, error) {
+	switch p := OrderingPolicy(policy); p {
+	case OrderingNone, OrderingFeeShuffle:
+		return p, nil
+	}
+
+	return "", fmt.Errorf("unknown ordering policy %q", policy)
+}
+
+// OrderTransactions returns the transactions in the order required by the
+// policy. The parent block hash seeds the shuffle so the miner can't choose
+// the outcome. Transactions from the same account always remain in nonce order.
+func OrderTransactions(policy OrderingPolicy, prevBlockHash string, trans []transaction.BlockTx) ([]transaction.BlockTx, error) {
+	switch policy {
+	case OrderingNone:
+		return trans, nil
+
+	case OrderingFeeShuffle:
+		return orderFeeShuffle(prevBlockHash, trans)
+	}
+
+	return nil, fmt.Errorf("unknown ordering policy %q", policy)
+}
+
+// ValidateOrdering checks the transactions in the block follow the order
+// required by the policy.
+func (b Block) ValidateOrdering(policy OrderingPolicy) error {
+	if policy == OrderingNone || b.MerkleTree == nil {
+		return nil
+	}
+
+	trans := b.MerkleTree.Values()
+
+	exp, err := OrderTransactions(policy, b.Header.PrevBlockHash, trans)
+	if err != nil {
+		return err
+	}
+
+	for i := range trans {
+		if !trans[i].Equals(exp[i]) {
+			return fmt.Errorf("transaction %d is out of order for policy %q, got %s, exp %s", i, policy, trans[i], exp[i])
+		}
+	}
+
+	return nil
+}
+
+// =============================================================================
+
+// orderFeeShuffle implements the fee shuffle ordering policy.
+func orderFeeShuffle(prevBlockHash string, trans []transaction.BlockTx) ([]transaction.BlockTx, error) {
+	type sortKey struct {
+		bucket int
+		key    []byte
+		tx     transaction.BlockTx
+	}
+
+	keys := make([]sortKey, len(trans))
+	for i, tx := range trans {
+		txHash, err := tx.Hash()
+		if err != nil {
+			return nil, err
+		}
+
+		key := sha256.Sum256(append([]byte(prevBlockHash), txHash...))
+		keys[i] = sortKey{
+			bucket: tx.Tip.BitLen(),
+			key:    key[:],
+			tx:     tx,
+		}
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		if keys[i].bucket != keys[j].bucket {
+			return keys[i].bucket > keys[j].bucket
+		}
+		return bytes.Compare(keys[i].key, keys[j].key) < 0
+	})
+
+	ordered := make([]transaction.BlockTx, len(keys))
+	for i := range keys {
+		ordered[i] = keys[i].tx
+	}
+
+	return restoreNonceOrder(ordered), nil
+}
+
+// restoreNonceOrder keeps the positions each account was given in the block
+// but places that account's transactions into those positions in nonce order.
+func restoreNonceOrder(trans []transaction.BlockTx) []transaction.BlockTx {
+	positions := make(map[acc.AccountID][]int)
+	for i, tx := range trans {
+		positions[tx.FromID] = append(positions[tx.FromID], i)
+	}
+
+	ordered := make([]transaction.BlockTx, len(trans))
+	for _, idxs := range positions {
+		accTrans := make([]transaction.BlockTx, len(idxs))
+		for i, idx := range idxs {
+			accTrans[i] = trans[idx]
+		}
+
+		sort.SliceStable(accTrans, func(i, j int) bool {
+			return accTrans[i].Nonce < accTrans[j].Nonce
+		})
+
+		for i, idx := range idxs {
+			ordered[idx] = accTrans[i]
+		}
+	}
+
+	return ordered
+}
@@ -0,0 +1,195 @@
+package block_test
+
+import (
+	"crypto/ecdsa"
+	"testing"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/chaintest"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+)
+
+const toID = acc.AccountID("0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76")
+
+// keys holds a private key for each of the accounts used by the tests.
+var keys = map[string]*ecdsa.PrivateKey{
+	"a": chaintest.Key(1),
+	"b": chaintest.Key(2),
+	"c": chaintest.Key(3),
+	"d": chaintest.Key(4),
+}
+
+// accountID returns the account id of the named test account.
+func accountID(name string) acc.AccountID {
+	return acc.PublicKeyToAccountID(keys[name].PublicKey)
+}
+
+// signedTx constructs a signed transaction from the named account.
+func signedTx(t *testing.T, from string, nonce uint64, tip uint64, gasUnits uint64) transaction.BlockTx {
+	t.Helper()
+
+	tx, err := transaction.NewTx(1, nonce, accountID(from), toID, amount.New(1), amount.New(tip), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	signed, err := tx.Sign(keys[from])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return transaction.BlockTx{SignedTx: signed, GasPrice: amount.New(1), GasUnits: gasUnits}
+}
+
+// =============================================================================
+
+func Test_ToOrderingPolicy(t *testing.T) {
+	type table struct {
+		testCaseID int
+		value      string
+		expected   block.OrderingPolicy
+		success    bool
+	}
+
+	tt := []table{
+		{testCaseID: 0, value: "", expected: block.OrderingNone, success: true},
+		{testCaseID: 1, value: "fee_shuffle", expected: block.OrderingFeeShuffle, success: true},
+		{testCaseID: 2, value: "random"},
+	}
+
+	for _, tst := range tt {
+		got, err := block.ToOrderingPolicy(tst.value)
+
+		if !tst.success {
+			if err == nil {
+				t.Errorf("[case:%d] error: expected %q to fail got %s", tst.testCaseID, tst.value, got)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+		if got != tst.expected {
+			t.Errorf("[case:%d] error: expected %q got %q", tst.testCaseID, tst.expected, got)
+		}
+	}
+}
+
+func Test_OrderFeeShuffle(t *testing.T) {
+	trans := []transaction.BlockTx{
+		signedTx(t, "a", 1, 1, 1),
+		signedTx(t, "a", 2, 100, 1),
+		signedTx(t, "b", 1, 40, 1),
+		signedTx(t, "b", 2, 50, 1),
+		signedTx(t, "c", 1, 5, 1),
+		signedTx(t, "c", 2, 6, 1),
+		signedTx(t, "d", 1, 0, 1),
+	}
+
+	type table struct {
+		testCaseID    int
+		prevBlockHash string
+	}
+
+	tt := []table{
+		{testCaseID: 0, prevBlockHash: "0x0000000000000000000000000000000000000000000000000000000000000000"},
+		{testCaseID: 1, prevBlockHash: "0x1111111111111111111111111111111111111111111111111111111111111111"},
+		{testCaseID: 2, prevBlockHash: "0x2222222222222222222222222222222222222222222222222222222222222222"},
+	}
+
+	for _, tst := range tt {
+		ordered, err := block.OrderTransactions(block.OrderingFeeShuffle, tst.prevBlockHash, trans)
+		if err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+
+		if len(ordered) != len(trans) {
+			t.Errorf("[case:%d] error: expected %d transactions got %d", tst.testCaseID, len(trans), len(ordered))
+			continue
+		}
+
+		// The same parent always produces the same order.
+		again, err := block.OrderTransactions(block.OrderingFeeShuffle, tst.prevBlockHash, trans)
+		if err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+		for i := range ordered {
+			if !ordered[i].Equals(again[i]) {
+				t.Errorf("[case:%d] error: expected the same order for the same parent at %d", tst.testCaseID, i)
+			}
+		}
+
+		// Each account's transactions remain in nonce order.
+		nonces := make(map[acc.AccountID]uint64)
+		for _, tx := range ordered {
+			if tx.Nonce <= nonces[tx.FromID] {
+				t.Errorf("[case:%d] error: expected %s nonce %d after nonce %d", tst.testCaseID, tx.FromID, tx.Nonce, nonces[tx.FromID])
+			}
+			nonces[tx.FromID] = tx.Nonce
+		}
+
+		// The account holding the largest tip bucket goes first and the
+		// account without a tip goes last.
+		if ordered[0].FromID != accountID("a") {
+			t.Errorf("[case:%d] error: expected account a first got %s", tst.testCaseID, ordered[0].FromID)
+		}
+		if last := ordered[len(ordered)-1]; last.FromID != accountID("d") {
+			t.Errorf("[case:%d] error: expected account d last got %s", tst.testCaseID, last.FromID)
+		}
+	}
+
+	none, err := block.OrderTransactions(block.OrderingNone, "", trans)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := range trans {
+		if !none[i].Equals(trans[i]) {
+			t.Errorf("error: expected no ordering to keep transaction %d in place", i)
+		}
+	}
+
+	if _, err := block.OrderTransactions("random", "", trans); err == nil {
+		t.Errorf("error: expected an unknown policy to fail")
+	}
+}
+
+func Test_ValidateOrdering(t *testing.T) {
+	trans := []transaction.BlockTx{
+		signedTx(t, "a", 1, 1, 1),
+		signedTx(t, "b", 1, 40, 1),
+		signedTx(t, "c", 1, 5, 1),
+		signedTx(t, "d", 1, 300, 1),
+	}
+
+	policy := block.BuildPolicy{Ordering: block.OrderingFeeShuffle, BeneficiaryID: toID}
+	b, err := block.BuildBlock(block.Block{}, trans, policy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := b.ValidateOrdering(block.OrderingFeeShuffle); err != nil {
+		t.Errorf("error: expected the built block to be in order: %v", err)
+	}
+
+	// Swap the first two transactions to break the order.
+	values := b.MerkleTree.Values()
+	values[0], values[1] = values[1], values[0]
+
+	swapped, err := block.New(b.Header, values)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := swapped.ValidateOrdering(block.OrderingFeeShuffle); err == nil {
+		t.Errorf("error: expected the swapped block to be out of order")
+	}
+	if err := swapped.ValidateOrdering(block.OrderingNone); err != nil {
+		t.Errorf("error: expected any order to be valid without a policy: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+// Package chaintest provides support for testing the blockchain packages.
+package chaintest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"testing"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/proof"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Key constructs a private key from the specified seed. The same seed
+// always produces the same key, so tests work with known account ids.
+func Key(seed int) *ecdsa.PrivateKey {
+	pk, err := crypto.HexToECDSA(fmt.Sprintf("%064x", seed))
+	if err != nil {
+		panic(err)
+	}
+
+	return pk
+}
+
+// AccountID returns the account id of the key for the specified seed.
+func AccountID(seed int) acc.AccountID {
+	return acc.PublicKeyToAccountID(Key(seed).PublicKey)
+}
+
+// Mine seals the block with a proof of work at the block's difficulty.
+func Mine(t testing.TB, b block.Block) block.Block {
+	t.Helper()
+
+	mined, _, err := proof.POW(context.Background(), b, 2)
+	if err != nil {
+		t.Fatalf("unexpected error mining block: %v", err)
+	}
+
+	return mined
+}
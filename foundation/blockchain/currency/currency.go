@@ -0,0 +1,124 @@
+// Package currency provides support for converting amounts between the base
+// unit stored on chain and the denominations humans read and type.
+package currency
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+)
+
+// Denomination represents a named unit of the currency. Decimals is the power
+// of ten the denomination is worth in base units.
+type Denomination struct {
+	Name     string
+	Decimals uint8
+}
+
+// Set of denominations supported by the blockchain. Amounts on chain are
+// always stored in Wei, the base unit.
+var (
+	Wei   = Denomination{Name: "wei", Decimals: 0}
+	Gwei  = Denomination{Name: "gwei", Decimals: 9}
+	Ether = Denomination{Name: "ether", Decimals: 18}
+)
+
+// denominations maps the names that can be used to the denomination.
+var denominations = map[string]Denomination{
+	Wei.Name:   Wei,
+	Gwei.Name:  Gwei,
+	Ether.Name: Ether,
+}
+
+// ToDenomination converts a string to a denomination.
+func ToDenomination(name string) (Denomination, error) {
+	d, exists := denominations[strings.ToLower(name)]
+	if !exists {
+		return Denomination{}, fmt.Errorf("unknown denomination %q", name)
+	}
+
+	return d, nil
+}
+
+// String implements the Stringer interface.
+func (d Denomination) String() string {
+	return d.Name
+}
+
+// =============================================================================
+
+// Parse converts a decimal string like "1.5" in the specified denomination
+// into an amount in base units. An error is returned if the value has more
+// fractional digits than the denomination supports, since that would be a
+// fraction of a base unit.
+func Parse(value string, d Denomination) (amount.Amount, error) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), "_", "")
+
+	whole, frac, _ := strings.Cut(value, ".")
+	if whole == "" && frac == "" {
+		return amount.Amount{}, fmt.Errorf("invalid %s value %q", d, value)
+	}
+
+	if len(frac) > int(d.Decimals) {
+		return amount.Amount{}, fmt.Errorf("invalid %s value %q, at most %d decimal places are allowed", d, value, d.Decimals)
+	}
+
+	digits := whole + frac + strings.Repeat("0", int(d.Decimals)-len(frac))
+	if strings.Trim(digits, "0123456789") != "" {
+		return amount.Amount{}, fmt.Errorf("invalid %s value %q", d, value)
+	}
+
+	a, err := amount.Parse(digits)
+	if err != nil {
+		return amount.Amount{}, fmt.Errorf("invalid %s value %q: %w", d, value, err)
+	}
+
+	return a, nil
+}
+
+// ParseWithDenomination converts a string like "1.5 ether" into an amount in
+// base units. A value without a denomination is treated as base units.
+func ParseWithDenomination(value string) (amount.Amount, error) {
+	fields := strings.Fields(value)
+
+	switch len(fields) {
+	case 1:
+		return Parse(fields[0], Wei)
+
+	case 2:
+		d, err := ToDenomination(fields[1])
+		if err != nil {
+			return amount.Amount{}, err
+		}
+		return Parse(fields[0], d)
+	}
+
+	return amount.Amount{}, fmt.Errorf("invalid value %q, expecting <value> [denomination]", value)
+}
+
+// Format converts an amount in base units into a decimal string in the
+// specified denomination. Trailing fractional zeros are removed.
+func Format(a amount.Amount, d Denomination) string {
+	digits := a.String()
+	decimals := int(d.Decimals)
+
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+
+	whole := digits[:len(digits)-decimals]
+	frac := strings.TrimRight(digits[len(digits)-decimals:], "0")
+
+	if frac == "" {
+		return whole
+	}
+
+	return whole + "." + frac
+}
+
+// FormatWithDenomination converts an amount in base units into a decimal
+// string in the specified denomination followed by the denomination's name.
+func FormatWithDenomination(a amount.Amount, d Denomination) string {
+	return Format(a, d) + " " + d.Name
+}
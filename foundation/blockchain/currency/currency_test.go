@@ -0,0 +1,92 @@
+package currency_test
+
+import (
+	"testing"
+
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+	"github.com/dudakovict/blockchain/foundation/blockchain/currency"
+)
+
+func Test_ParseWithDenomination(t *testing.T) {
+	type table struct {
+		testCaseID int
+		value      string
+		expected   string
+		success    bool
+	}
+
+	tt := []table{
+		{testCaseID: 0, value: "1.5 ether", expected: "1500000000000000000", success: true},
+		{testCaseID: 1, value: "0.000000001 ether", expected: "1000000000", success: true},
+		{testCaseID: 2, value: "12 gwei", expected: "12000000000", success: true},
+		{testCaseID: 3, value: "12 GWEI", expected: "12000000000", success: true},
+		{testCaseID: 4, value: "7", expected: "7", success: true},
+		{testCaseID: 5, value: "0 ether", expected: "0", success: true},
+		{testCaseID: 6, value: ".5 ether", expected: "500000000000000000", success: true},
+		{testCaseID: 7, value: "1_000 wei", expected: "1000", success: true},
+		{testCaseID: 8, value: "1.5 wei"},
+		{testCaseID: 9, value: "-1 ether"},
+		{testCaseID: 10, value: "1e3"},
+		{testCaseID: 11, value: "1 foo"},
+		{testCaseID: 12, value: ". ether"},
+		{testCaseID: 13, value: "1.2.3 ether"},
+		{testCaseID: 14, value: "0.0000000000000000001 ether"},
+		{testCaseID: 15, value: "1 ether extra"},
+		{testCaseID: 16, value: "1000000000000000000000000000000000000000000000000000000000000 ether"},
+	}
+
+	for _, tst := range tt {
+		a, err := currency.ParseWithDenomination(tst.value)
+
+		if !tst.success {
+			if err == nil {
+				t.Errorf("[case:%d] error: expected %q to fail got %s", tst.testCaseID, tst.value, a)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+		if a.String() != tst.expected {
+			t.Errorf("[case:%d] error: expected %s got %s", tst.testCaseID, tst.expected, a)
+		}
+	}
+}
+
+func Test_Format(t *testing.T) {
+	type table struct {
+		testCaseID   int
+		value        uint64
+		denomination currency.Denomination
+		expected     string
+	}
+
+	tt := []table{
+		{testCaseID: 0, value: 1500000000000000000, denomination: currency.Ether, expected: "1.5"},
+		{testCaseID: 1, value: 1000000000, denomination: currency.Ether, expected: "0.000000001"},
+		{testCaseID: 2, value: 12000000000, denomination: currency.Ether, expected: "0.000000012"},
+		{testCaseID: 3, value: 7, denomination: currency.Ether, expected: "0.000000000000000007"},
+		{testCaseID: 4, value: 0, denomination: currency.Ether, expected: "0"},
+		{testCaseID: 5, value: 12000000000, denomination: currency.Gwei, expected: "12"},
+		{testCaseID: 6, value: 7, denomination: currency.Wei, expected: "7"},
+	}
+
+	for _, tst := range tt {
+		a := amount.New(tst.value)
+
+		if got := currency.Format(a, tst.denomination); got != tst.expected {
+			t.Errorf("[case:%d] error: expected %s got %s", tst.testCaseID, tst.expected, got)
+		}
+
+		back, err := currency.Parse(tst.expected, tst.denomination)
+		if err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+		if back.Cmp(a) != 0 {
+			t.Errorf("[case:%d] error: expected %s after a round trip got %s", tst.testCaseID, a, back)
+		}
+	}
+}
@@ -5,53 +5,148 @@ package database
 import (
 	"errors"
 	"fmt"
+	"math/big"
 	"sort"
 	"sync"
 
 	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
 	"github.com/dudakovict/blockchain/foundation/blockchain/block"
 	"github.com/dudakovict/blockchain/foundation/blockchain/genesis"
+	"github.com/dudakovict/blockchain/foundation/blockchain/proof"
 	"github.com/dudakovict/blockchain/foundation/blockchain/signature"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage"
 	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 )
 
+// ErrForkNotHeavier is returned when a fork holds no more work than the
+// blocks of our chain it would replace.
+var ErrForkNotHeavier = errors.New("fork doesn't hold more work than our chain")
+
 // =============================================================================
 
 // Database manages data related to accounts who have transacted on the blockchain.
 type Database struct {
 	mu          sync.RWMutex
+	blockMu     sync.Mutex
 	genesis     genesis.Genesis
+	rules       proof.ChainRules
+	storage     storage.Storage
 	latestBlock block.Block
+	chainWork   *big.Int
+	rewards     map[uint64]block.Rewards
 	accounts    map[acc.AccountID]acc.Account
+	modules     map[string]Module
+	preTxHooks  []PreTxHook
+	postTxHooks []PostTxHook
 }
 
-// New constructs a new database and applies account genesis information and
-// reads/writes the blockchain database on disk if a dbPath is provided.
-func New(genesis genesis.Genesis) (*Database, error) {
+// New constructs a new database and applies account genesis information. The
+// blocks already held by the storage are replayed to rebuild the account
+// state the node had before it was stopped.
+func New(genesis genesis.Genesis, storage storage.Storage) (*Database, error) {
+	rules, err := genesis.ChainRules()
+	if err != nil {
+		return nil, err
+	}
+
 	db := Database{
-		genesis:  genesis,
-		accounts: make(map[acc.AccountID]acc.Account),
+		genesis: genesis,
+		rules:   rules,
+		storage: storage,
+		modules: map[string]Module{
+			transaction.TypeTransfer:       transferModule{},
+			transaction.TypeRotateKey:      rotateKeyModule{},
+			transaction.TypeSetGuardians:   setGuardiansModule{},
+			transaction.TypeRecoverAccount: recoverAccountModule{delay: genesis.RecoveryDelay},
+			transaction.TypeGrantFee:       grantFeeModule{},
+		},
 	}
 
 	// Update the database with account balance information from genesis.
-	for accountStr, balance := range genesis.Balances {
-		accountID, err := acc.ToAccountID(accountStr)
+	if err := db.resetState(); err != nil {
+		return nil, err
+	}
+
+	// Read all the blocks from storage and apply them to the database.
+	if err := db.replay(); err != nil {
+		return nil, err
+	}
+
+	return &db, nil
+}
+
+// replay applies every block held by the storage in order. A block that
+// doesn't extend the previous block stops the database from starting.
+func (db *Database) replay() error {
+	f := func(blockData storage.BlockData) error {
+		b, err := storage.ToBlock(blockData)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		db.accounts[accountID] = acc.New(accountID, balance)
+
+		if err := db.applyBlock(b); err != nil {
+			return fmt.Errorf("replaying block %d: %w", b.Header.Number, err)
+		}
+
+		db.UpdateLatestBlock(b)
+		return nil
 	}
 
-	return &db, nil
+	return db.storage.ForEach(f)
+}
+
+// Close closes the storage being used by the database.
+func (db *Database) Close() error {
+	return db.storage.Close()
 }
 
-// Reset re-initializes the database back to the genesis state.
+// Reset re-initializes the database back to the genesis state and removes
+// the blocks from storage.
 func (db *Database) Reset() error {
+	db.blockMu.Lock()
+	defer db.blockMu.Unlock()
+
+	if err := db.storage.Reset(); err != nil {
+		return err
+	}
+
+	return db.resetState()
+}
+
+// Truncate removes every block after the specified block number and rebuilds
+// the account state from the blocks that remain. This is used to abandon
+// the blocks of a fork that lost to a longer chain.
+func (db *Database) Truncate(num uint64) error {
+	db.blockMu.Lock()
+	defer db.blockMu.Unlock()
+
+	return db.truncate(num)
+}
+
+// truncate removes every block after the specified block number and
+// rebuilds the account state. The caller must hold the block lock.
+func (db *Database) truncate(num uint64) error {
+	if err := db.storage.Truncate(num); err != nil {
+		return err
+	}
+
+	if err := db.resetState(); err != nil {
+		return err
+	}
+
+	return db.replay()
+}
+
+// resetState initializes the accounts back to the genesis information.
+func (db *Database) resetState() error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
-	// Initializes the database back to the genesis information.
 	db.latestBlock = block.Block{}
+	db.chainWork = new(big.Int)
+	db.rewards = make(map[uint64]block.Rewards)
 	db.accounts = make(map[acc.AccountID]acc.Account)
 	for accountStr, balance := range db.genesis.Balances {
 		accountID, err := acc.ToAccountID(accountStr)
@@ -114,90 +209,591 @@ func (db *Database) HashState() string {
 	return signature.Hash(accounts)
 }
 
-// ApplyMiningReward gives the specififed account the mining reward.
-func (db *Database) ApplyMiningReward(b block.Block) {
+// HashShardStates returns a hash for each shard based on the contents of
+// the accounts that belong to the shard. Nothing is returned when sharding
+// is disabled for the chain.
+func (db *Database) HashShardStates() []string {
+	shards := db.genesis.Shards
+	if shards <= 1 {
+		return nil
+	}
+
+	shardAccounts := make([][]acc.Account, shards)
+	db.mu.RLock()
+	{
+		for accountID, account := range db.accounts {
+			shard := accountID.Shard(shards)
+			shardAccounts[shard] = append(shardAccounts[shard], account)
+		}
+	}
+	db.mu.RUnlock()
+
+	roots := make([]string, shards)
+	for shard, accounts := range shardAccounts {
+		sort.Sort(acc.ByAccount(accounts))
+		roots[shard] = signature.Hash(accounts)
+	}
+
+	return roots
+}
+
+// Consensus returns the consensus algorithm the chain was configured with.
+func (db *Database) Consensus() proof.Consensus {
+	return db.rules.Consensus
+}
+
+// MiningReward returns the mining reward a block with the specified number
+// must claim. The reward is not at the miner's discretion.
+func (db *Database) MiningReward(number uint64) amount.Amount {
+	return db.genesis.MiningRewardAt(number)
+}
+
+// ApplyMiningReward gives the specififed account the mining reward. The
+// reward claimed by the block is validated against the reward schedule.
+func (db *Database) ApplyMiningReward(b block.Block) error {
+	if exp := db.MiningReward(b.Header.Number); b.Header.MiningReward.Cmp(exp) != 0 {
+		return fmt.Errorf("block mining reward is invalid, got %s, exp %s", b.Header.MiningReward, exp)
+	}
+
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
-	account := db.accounts[b.Header.BeneficiaryID]
-	account.Balance += b.Header.MiningReward
+	account := queryOrNew(db.accounts, b.Header.BeneficiaryID, b.Header.Number)
+
+	balance, err := account.Balance.Add(b.Header.MiningReward)
+	if err != nil {
+		return fmt.Errorf("applying mining reward: %w", err)
+	}
+	account.Balance = balance
 
 	db.accounts[b.Header.BeneficiaryID] = account
+
+	return nil
 }
 
 // ApplyTransaction performs the business logic for applying a transaction
-// to the database.
+// to the database. Any registered hooks are executed around the transaction.
 func (db *Database) ApplyTransaction(b block.Block, tx transaction.BlockTx) error {
+	preHooks, postHooks := db.hooks()
+
+	_, err := db.runTransaction(preHooks, b, tx)
+
+	for _, hook := range postHooks {
+		hook.PostApply(b, tx, err)
+	}
+
+	return err
+}
+
+// runTransaction executes the pre hooks and applies the transaction when
+// none of them reject it. What the transaction was charged is returned even
+// when it fails.
+func (db *Database) runTransaction(preHooks []PreTxHook, b block.Block, tx transaction.BlockTx) (block.TxFee, error) {
+	fee := block.TxFee{
+		FromID: tx.FromID,
+		Nonce:  tx.Nonce,
+	}
+
+	txHash, err := tx.Hash()
+	if err != nil {
+		return fee, err
+	}
+	fee.TxHash = hexutil.Encode(txHash)
+
+	for _, hook := range preHooks {
+		if err := hook.PreApply(b, tx); err != nil {
+			return fee, err
+		}
+	}
+
+	err = db.applyTransaction(b, tx, &fee)
+	return fee, err
+}
+
+// applyTransaction performs the accounting required to apply a transaction
+// to the database. The changes specific to the type of transaction are
+// delegated to the module registered for that type. The amounts charged
+// are recorded in the fee as they are taken. Nothing is burned under the
+// chain's fee rules, the whole gas fee goes to the beneficiary.
+func (db *Database) applyTransaction(b block.Block, tx transaction.BlockTx, fee *block.TxFee) error {
+
+	// The transaction must be signed by the from account for this chain
+	// before any balances are touched.
+	if err := tx.Validate(db.genesis.ChainID); err != nil {
+		return err
+	}
+
+	module, err := db.module(tx.Tx)
+	if err != nil {
+		return err
+	}
+
+	if err := module.Validate(tx); err != nil {
+		return err
+	}
+
+	gasUnits, err := db.GasUnits(tx.Tx)
+	if err != nil {
+		return err
+	}
+
+	if tx.GasUnits != gasUnits {
+		return fmt.Errorf("transaction invalid, wrong gas units, got %d, exp %d", tx.GasUnits, gasUnits)
+	}
+
+	gasFee, err := tx.GasPrice.MulUint64(tx.GasUnits)
+	if err != nil {
+		return fmt.Errorf("transaction invalid, gas fee: %w", err)
+	}
+
+	needed, err := tx.Value.Add(tx.Tip)
+	if err != nil {
+		return fmt.Errorf("transaction invalid, value and tip: %w", err)
+	}
+
 	db.mu.Lock()
 	defer db.mu.Unlock()
 	{
-		// Capture these accounts from the database.
-		from, exists := db.accounts[tx.FromID]
-		if !exists {
-			from = acc.New(tx.FromID, 0)
+		// Capture these accounts from the database. The account can be
+		// the beneficiary, so the same value is used for both to keep the
+		// two from overwriting each other.
+		from := queryOrNew(db.accounts, tx.FromID, b.Header.Number)
+		bnfc := &from
+		if b.Header.BeneficiaryID != from.AccountID {
+			beneficiary := queryOrNew(db.accounts, b.Header.BeneficiaryID, b.Header.Number)
+			bnfc = &beneficiary
 		}
 
-		to, exists := db.accounts[tx.ToID]
-		if !exists {
-			to = acc.New(tx.ToID, 0)
-		}
+		// A fee granter pays the gas fee when its grant covers the fee. If
+		// it doesn't, the account pays the fee and the transaction fails.
+		// The granter can be the beneficiary, so the same value is used
+		// for both to keep the two from overwriting each other.
+		var granter acc.Account
+		var grantErr error
+		payer := &from
+		if tx.FeeGranter != "" {
+			grntr := &granter
+			if tx.FeeGranter == bnfc.AccountID {
+				grntr = bnfc
+			} else {
+				granter = queryOrNew(db.accounts, tx.FeeGranter, b.Header.Number)
+			}
 
-		bnfc, exists := db.accounts[b.Header.BeneficiaryID]
-		if !exists {
-			bnfc = acc.New(b.Header.BeneficiaryID, 0)
+			if grantErr = useFeeGrant(grntr, tx.FromID, gasFee); grantErr == nil {
+				payer = grntr
+			}
 		}
 
 		// The account needs to pay the gas fee regardless. Take the
 		// remaining balance if the account doesn't hold enough for the
 		// full amount of gas. This is the only way to stop bad actors.
-		gasFee := tx.GasPrice * tx.GasUnits
-		if gasFee > from.Balance {
-			gasFee = from.Balance
+		gasFee = gasFee.Min(payer.Balance)
+		if payer != bnfc {
+			if err := transfer(payer, bnfc, gasFee); err != nil {
+				return err
+			}
 		}
-		from.Balance -= gasFee
-		bnfc.Balance += gasFee
+		fee.PaidBy = payer.AccountID
+		fee.GasFee = gasFee
 
 		// Make sure these changes get applied.
-		db.accounts[tx.FromID] = from
-		db.accounts[b.Header.BeneficiaryID] = bnfc
+		db.accounts[from.AccountID] = from
+		if payer == &granter {
+			db.accounts[granter.AccountID] = granter
+		}
+		db.accounts[bnfc.AccountID] = *bnfc
 
 		// Perform basic accounting checks.
 		{
+			if grantErr != nil {
+				return grantErr
+			}
+
+			if from.MigratedTo != "" {
+				return fmt.Errorf("transaction invalid, account key was rotated to %s", from.MigratedTo)
+			}
+
 			if tx.Nonce != (from.Nonce + 1) {
 				return fmt.Errorf("transaction invalid, wrong nonce, got %d, exp %d", tx.Nonce, from.Nonce+1)
 			}
 
-			if from.Balance == 0 || from.Balance < (tx.Value+tx.Tip) {
-				return fmt.Errorf("transaction invalid, insufficient funds, bal %d, needed %d", from.Balance, (tx.Value + tx.Tip))
+			// An account with a fee grant can send transactions without
+			// a balance of its own as long as they carry no value or tip.
+			if (payer == &from && from.Balance.IsZero()) || from.Balance.LessThan(needed) {
+				return fmt.Errorf("transaction invalid, insufficient funds, bal %s, needed %s", from.Balance, needed)
 			}
 		}
 
-		// Update the balances between the two parties.
-		from.Balance -= tx.Value
-		to.Balance += tx.Value
-
 		// Give the beneficiary the tip.
-		from.Balance -= tx.Tip
-		bnfc.Balance += tx.Tip
+		if err := transfer(&from, bnfc, tx.Tip); err != nil {
+			return err
+		}
 
 		// Update the nonce for the next transaction check.
 		from.Nonce = tx.Nonce
+		from.Sent++
+
+		// Let the module apply the changes for this type of transaction.
+		// Nothing is committed if the module fails.
+		state := newPendingState(db.accounts, b.Header.Number)
+		state.SetAccount(from)
+		state.SetAccount(*bnfc)
+
+		if err := module.Execute(state, b, tx); err != nil {
+			return err
+		}
 
 		// Update the final changes to these accounts.
-		db.accounts[tx.FromID] = from
-		db.accounts[tx.ToID] = to
-		db.accounts[b.Header.BeneficiaryID] = bnfc
+		state.commit()
+		fee.Tip = tx.Tip
+	}
+
+	return nil
+}
+
+// Write adds a new block to the storage.
+func (db *Database) Write(b block.Block) error {
+	return db.storage.Write(storage.NewBlockData(b))
+}
+
+// GetBlock returns the specified block from storage.
+func (db *Database) GetBlock(num uint64) (block.Block, error) {
+	blockData, err := db.storage.GetBlock(num)
+	if err != nil {
+		return block.Block{}, err
+	}
+
+	return storage.ToBlock(blockData)
+}
+
+// ApplyBlock validates the block extends the latest block and applies it to
+// the database. The block is then written to storage and becomes the latest
+// block. Blocks are applied one at a time.
+func (db *Database) ApplyBlock(b block.Block) error {
+	db.blockMu.Lock()
+	defer db.blockMu.Unlock()
+
+	snapshot := db.Copy()
+
+	if err := db.applyBlock(b); err != nil {
+		return err
+	}
+
+	if err := db.Write(b); err != nil {
+		db.discardBlock(b, snapshot)
+		return err
+	}
+
+	db.UpdateLatestBlock(b)
+
+	return nil
+}
+
+// Reorganize switches the chain to the blocks of a fork that branches off
+// after the ancestor block. Nothing changes unless the fork's headers are
+// valid and the fork holds more work than the blocks of our chain it
+// replaces. A fork that extends our chain has an ancestor of our latest
+// block. If a block of the fork is rejected once its transactions are
+// applied, the blocks that were replaced are restored.
+func (db *Database) Reorganize(ancestor uint64, blocks []block.Block) error {
+	db.blockMu.Lock()
+	defer db.blockMu.Unlock()
+
+	latest := db.LatestBlock().Header.Number
+	if ancestor > latest {
+		return fmt.Errorf("ancestor block %d is after our latest block %d", ancestor, latest)
+	}
+
+	v, err := db.headerVerifier(ancestor)
+	if err != nil {
+		return err
+	}
+
+	forkWork := new(big.Int)
+	for _, b := range blocks {
+		if err := v.Verify(b.Header); err != nil {
+			return fmt.Errorf("verifying fork: %w", err)
+		}
+		forkWork.Add(forkWork, proof.BlockWork(b.Header))
+	}
+
+	var replaced []block.Block
+	ourWork := new(big.Int)
+	for num := ancestor + 1; num <= latest; num++ {
+		b, err := db.GetBlock(num)
+		if err != nil {
+			return err
+		}
+		replaced = append(replaced, b)
+		ourWork.Add(ourWork, proof.BlockWork(b.Header))
+	}
+
+	if forkWork.Cmp(ourWork) <= 0 {
+		return ErrForkNotHeavier
+	}
+
+	if err := db.switchBlocks(ancestor, blocks); err != nil {
+		if restoreErr := db.switchBlocks(ancestor, replaced); restoreErr != nil {
+			return fmt.Errorf("restoring our chain after %s: %w", err, restoreErr)
+		}
+		return err
 	}
 
 	return nil
 }
 
-// UpdateLatestBlock provides safe access to update the latest block.
+// headerVerifier constructs a verifier that continues from the ancestor
+// block. The blocks the verifier needs to check the difficulty of the next
+// block are trusted since they are already part of our chain.
+func (db *Database) headerVerifier(ancestor uint64) (*proof.HeaderVerifier, error) {
+	v := proof.NewHeaderVerifier(db.rules)
+
+	start := uint64(1)
+	if ancestor > db.rules.Retarget.Blocks {
+		start = ancestor - db.rules.Retarget.Blocks
+	}
+
+	for num := start; num <= ancestor; num++ {
+		b, err := db.GetBlock(num)
+		if err != nil {
+			return nil, err
+		}
+		v.Trust(b.Header)
+	}
+
+	return v, nil
+}
+
+// switchBlocks removes the blocks after the ancestor block and applies the
+// specified blocks in their place. The caller must hold the block lock.
+func (db *Database) switchBlocks(ancestor uint64, blocks []block.Block) error {
+	if ancestor < db.LatestBlock().Header.Number {
+		if err := db.truncate(ancestor); err != nil {
+			return fmt.Errorf("truncating to block %d: %w", ancestor, err)
+		}
+	}
+
+	for _, b := range blocks {
+		snapshot := db.Copy()
+
+		if err := db.applyBlock(b); err != nil {
+			return fmt.Errorf("applying block %d: %w", b.Header.Number, err)
+		}
+
+		if err := db.Write(b); err != nil {
+			db.discardBlock(b, snapshot)
+			return err
+		}
+
+		db.UpdateLatestBlock(b)
+	}
+
+	return nil
+}
+
+// discardBlock undoes the changes applyBlock made for a block that couldn't
+// be written to storage, so the accounts match the latest block again.
+func (db *Database) discardBlock(b block.Block, snapshot map[acc.AccountID]acc.Account) {
+	db.restore(snapshot)
+
+	db.mu.Lock()
+	delete(db.rewards, b.Header.Number)
+	db.mu.Unlock()
+}
+
+// applyBlock validates the block against the latest block and applies its
+// transactions and mining reward. The state and shard roots in the header
+// must match the accounts once the block is applied. If they don't, the
+// accounts are restored and the block is rejected.
+func (db *Database) applyBlock(b block.Block) error {
+	latestBlock := db.LatestBlock()
+
+	difficulty, err := db.rules.HeaderDifficulty(b.Header, latestBlock.Header, db.header)
+	if err != nil {
+		return err
+	}
+
+	if err := b.ValidateBlock(latestBlock, difficulty, db.rules.ParentGasLimit(latestBlock.Header)); err != nil {
+		return err
+	}
+
+	if err := db.rules.ValidateSeal(b.Header, latestBlock.Header); err != nil {
+		return err
+	}
+
+	policy, err := block.ToOrderingPolicy(db.genesis.Ordering)
+	if err != nil {
+		return err
+	}
+
+	if err := b.ValidateOrdering(policy); err != nil {
+		return err
+	}
+
+	snapshot := db.Copy()
+
+	fees, txErrs, err := db.executeBlock(b)
+	if err == nil {
+		err = db.validateRoots(b)
+	}
+
+	var rewards block.Rewards
+	if err == nil {
+		rewards, err = block.NewRewards(b, fees)
+	}
+
+	if err != nil {
+		db.restore(snapshot)
+		return err
+	}
+
+	db.mu.Lock()
+	db.rewards[b.Header.Number] = rewards
+	db.mu.Unlock()
+
+	// The observers only hear about the transactions of accepted blocks.
+	_, postHooks := db.hooks()
+	for i, tx := range b.MerkleTree.Values() {
+		for _, hook := range postHooks {
+			hook.PostApply(b, tx, txErrs[i])
+		}
+	}
+
+	return nil
+}
+
+// ExecuteBlock runs the candidate block against the current accounts without
+// keeping the changes and returns the block with the state and shard roots
+// of the resulting accounts. Proposers use this to fill in the roots before
+// the block is sealed.
+func (db *Database) ExecuteBlock(b block.Block) (block.Block, error) {
+	db.blockMu.Lock()
+	defer db.blockMu.Unlock()
+
+	snapshot := db.Copy()
+	defer db.restore(snapshot)
+
+	if _, _, err := db.executeBlock(b); err != nil {
+		return block.Block{}, err
+	}
+
+	b.Header.StateRoot = db.HashState()
+	b.Header.ShardRoots = db.HashShardStates()
+
+	return b, nil
+}
+
+// executeBlock applies the block's transactions and mining reward to the
+// accounts and returns what each transaction was charged along with the
+// error it produced. Transactions that fail are still part of the block.
+// The gas fee is taken and every node rejects the transaction the same way.
+func (db *Database) executeBlock(b block.Block) ([]block.TxFee, []error, error) {
+	preHooks, _ := db.hooks()
+
+	trans := b.MerkleTree.Values()
+	fees := make([]block.TxFee, len(trans))
+	txErrs := make([]error, len(trans))
+	for i, tx := range trans {
+		fees[i], txErrs[i] = db.runTransaction(preHooks, b, tx)
+		if txErrs[i] != nil {
+			fees[i].Error = txErrs[i].Error()
+		}
+	}
+
+	if err := db.ApplyMiningReward(b); err != nil {
+		return nil, nil, err
+	}
+
+	return fees, txErrs, nil
+}
+
+// validateRoots checks the state and shard roots claimed by the block match
+// the accounts after the block was applied.
+func (db *Database) validateRoots(b block.Block) error {
+	if stateRoot := db.HashState(); b.Header.StateRoot != stateRoot {
+		return fmt.Errorf("block state root doesn't match, got %s, exp %s", b.Header.StateRoot, stateRoot)
+	}
+
+	shardRoots := db.HashShardStates()
+	if len(b.Header.ShardRoots) != len(shardRoots) {
+		return fmt.Errorf("block has %d shard roots, exp %d", len(b.Header.ShardRoots), len(shardRoots))
+	}
+
+	for shard, root := range shardRoots {
+		if b.Header.ShardRoots[shard] != root {
+			return fmt.Errorf("block shard %d root doesn't match, got %s, exp %s", shard, b.Header.ShardRoots[shard], root)
+		}
+	}
+
+	return nil
+}
+
+// restore replaces the accounts with a copy taken before a block was
+// applied. Accounts are replaced as whole values when they change, so the
+// copy isn't affected by the block.
+func (db *Database) restore(accounts map[acc.AccountID]acc.Account) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.accounts = accounts
+}
+
+// NextDifficulty calculates the difficulty the block after the parent must
+// be mined at from the retarget settings in genesis.
+func (db *Database) NextDifficulty(parent block.Block) (uint16, error) {
+	return db.rules.NextDifficulty(parent.Header, db.header)
+}
+
+// header returns the header of the block with the specified number.
+func (db *Database) header(num uint64) (block.BlockHeader, error) {
+	b, err := db.GetBlock(num)
+	return b.Header, err
+}
+
+// NextGasLimit calculates the gas limit for the block after the parent,
+// moved as far towards the miner's target as the chain allows. The first
+// block starts from the genesis gas limit and a target of zero keeps the
+// parent's limit.
+func (db *Database) NextGasLimit(parent block.Block, target uint64) uint64 {
+	limit := db.rules.ParentGasLimit(parent.Header)
+
+	if target == 0 {
+		return limit
+	}
+
+	return block.NextGasLimit(limit, target)
+}
+
+// UpdateLatestBlock provides safe access to update the latest block. The
+// block's work is added to the work of the chain.
 func (db *Database) UpdateLatestBlock(b block.Block) {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
 	db.latestBlock = b
+	db.chainWork = new(big.Int).Add(db.chainWork, proof.BlockWork(b.Header))
+}
+
+// Rewards returns the breakdown of what the beneficiary earned for the
+// specified block, from the fees charged when the block was applied.
+func (db *Database) Rewards(num uint64) (block.Rewards, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	rewards, exists := db.rewards[num]
+	if !exists {
+		return block.Rewards{}, fmt.Errorf("no rewards for block %d", num)
+	}
+
+	return rewards, nil
+}
+
+// ChainWork returns the total work of the blocks in the chain.
+func (db *Database) ChainWork() *big.Int {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return new(big.Int).Set(db.chainWork)
 }
 
 // LatestBlock returns the latest block.
@@ -207,3 +803,41 @@ func (db *Database) LatestBlock() block.Block {
 
 	return db.latestBlock
 }
+
+// =============================================================================
+
+// queryOrNew returns the specified account or a new account with a zero
+// balance that records the block it was first seen in.
+func queryOrNew(accounts map[acc.AccountID]acc.Account, accountID acc.AccountID, blockNumber uint64) acc.Account {
+	account, exists := accounts[accountID]
+	if !exists {
+		account = acc.New(accountID, amount.Amount{})
+		account.CreatedAt = blockNumber
+	}
+
+	return account
+}
+
+// transfer moves the specified amount between the two accounts. Neither
+// account is changed if the balances can't hold the result.
+func transfer(from *acc.Account, to *acc.Account, value amount.Amount) error {
+	fromBalance, err := from.Balance.Sub(value)
+	if err != nil {
+		return fmt.Errorf("transaction invalid, %s balance: %w", from.AccountID, err)
+	}
+
+	// An account paying itself only needs to hold the amount.
+	if from == to {
+		return nil
+	}
+
+	toBalance, err := to.Balance.Add(value)
+	if err != nil {
+		return fmt.Errorf("transaction invalid, %s balance: %w", to.AccountID, err)
+	}
+
+	from.Balance = fromBalance
+	to.Balance = toBalance
+
+	return nil
+}
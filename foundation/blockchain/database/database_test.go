@@ -0,0 +1,678 @@
+package database_test
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/chaintest"
+	"github.com/dudakovict/blockchain/foundation/blockchain/database"
+	"github.com/dudakovict/blockchain/foundation/blockchain/genesis"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage/disk"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage/memory"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+)
+
+const (
+	toID          = acc.AccountID("0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76")
+	beneficiaryID = acc.AccountID("0xFef311483Cc040e1A89fb9bb469eeB8A70935EF8")
+)
+
+// id returns the account id of the private key.
+func id(pk *ecdsa.PrivateKey) acc.AccountID {
+	return acc.PublicKeyToAccountID(pk.PublicKey)
+}
+
+// newDB constructs a database in memory from the genesis. The chain id is
+// always 1 and the balances are taken from the specified keys.
+func newDB(t *testing.T, gen genesis.Genesis, balances map[*ecdsa.PrivateKey]uint64) *database.Database {
+	t.Helper()
+
+	gen.ChainID = 1
+	gen.Balances = make(map[string]amount.Amount)
+	for pk, bal := range balances {
+		gen.Balances[string(id(pk))] = amount.New(bal)
+	}
+
+	db, err := database.New(gen, memory.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return db
+}
+
+// newTx constructs a transaction from the key's account with the next nonce
+// the database expects. The change function can set the type, data, fee
+// granter and gas price of the transaction before it's signed.
+func newTx(t *testing.T, db *database.Database, pk *ecdsa.PrivateKey, to acc.AccountID, value uint64, tip uint64, change func(tx *transaction.Tx)) transaction.BlockTx {
+	t.Helper()
+
+	var nonce uint64
+	if account, err := db.Query(id(pk)); err == nil {
+		nonce = account.Nonce
+	}
+
+	tx, err := transaction.NewTx(1, nonce+1, id(pk), to, amount.New(value), amount.New(tip), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if change != nil {
+		change(&tx)
+	}
+
+	signed, err := tx.Sign(pk)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return transaction.NewBlockTx(signed, amount.New(1), 1)
+}
+
+// typed returns a change function that sets the type and data of a
+// transaction.
+func typed(t *testing.T, typ string, data any) func(tx *transaction.Tx) {
+	t.Helper()
+
+	d, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return func(tx *transaction.Tx) {
+		tx.Type = typ
+		tx.Data = d
+	}
+}
+
+// balance returns the balance of the account held by the database.
+func balance(db *database.Database, accountID acc.AccountID) amount.Amount {
+	account, err := db.Query(accountID)
+	if err != nil {
+		return amount.Amount{}
+	}
+
+	return account.Balance
+}
+
+// candidate builds the next block of the database's chain with the state
+// and shard roots filled in, ready to be mined.
+func candidate(t *testing.T, db *database.Database, trans ...transaction.BlockTx) block.Block {
+	t.Helper()
+
+	parent := db.LatestBlock()
+
+	difficulty, err := db.NextDifficulty(parent)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	policy := block.BuildPolicy{
+		BeneficiaryID: beneficiaryID,
+		Difficulty:    difficulty,
+		MiningReward:  db.MiningReward(parent.Header.Number + 1),
+		GasLimit:      db.NextGasLimit(parent, 0),
+	}
+
+	b, err := block.BuildBlock(parent, trans, policy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err = db.ExecuteBlock(b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return b
+}
+
+// extend mines the next block of the database's chain with a transfer from
+// the key's account and applies it.
+func extend(t *testing.T, db *database.Database, pk *ecdsa.PrivateKey) block.Block {
+	t.Helper()
+
+	b := chaintest.Mine(t, candidate(t, db, newTx(t, db, pk, toID, 1, 0, nil)))
+	if err := db.ApplyBlock(b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return b
+}
+
+// failingStorage keeps the blocks in memory but fails to write the block
+// with the specified hash.
+type failingStorage struct {
+	*memory.Memory
+	hash string
+}
+
+func (s *failingStorage) Write(blockData storage.BlockData) error {
+	if blockData.Hash == s.hash {
+		return errors.New("disk full")
+	}
+
+	return s.Memory.Write(blockData)
+}
+
+// =============================================================================
+
+func Test_ApplyTransaction(t *testing.T) {
+	pk := chaintest.Key(1)
+	b := block.Block{Header: block.BlockHeader{Number: 3, BeneficiaryID: beneficiaryID}}
+
+	type table struct {
+		testCaseID int
+		tx         func(db *database.Database) transaction.BlockTx
+		from       uint64
+		to         uint64
+		bnfc       uint64
+		nonce      uint64
+		expected   string
+	}
+
+	tt := []table{
+		{
+			testCaseID: 0,
+			tx:         func(db *database.Database) transaction.BlockTx { return newTx(t, db, pk, toID, 100, 10, nil) },
+			from:       889, to: 100, bnfc: 11, nonce: 1,
+		},
+		{
+			testCaseID: 1,
+			tx: func(db *database.Database) transaction.BlockTx {
+				return newTx(t, db, pk, toID, 100, 0, func(tx *transaction.Tx) { tx.Nonce = 2 })
+			},
+			from: 999, bnfc: 1, expected: "wrong nonce",
+		},
+		{
+			testCaseID: 2,
+			tx:         func(db *database.Database) transaction.BlockTx { return newTx(t, db, pk, toID, 1000, 0, nil) },
+			from:       999, bnfc: 1, expected: "insufficient funds",
+		},
+		{
+			testCaseID: 3,
+			tx: func(db *database.Database) transaction.BlockTx {
+				return newTx(t, db, pk, toID, 1, 0, func(tx *transaction.Tx) { tx.ChainID = 2 })
+			},
+			from: 1000, expected: "chain",
+		},
+		{
+			testCaseID: 4,
+			tx: func(db *database.Database) transaction.BlockTx {
+				tx := newTx(t, db, pk, toID, 1, 0, nil)
+				tx.GasUnits = 2
+				return tx
+			},
+			from: 1000, expected: "gas units",
+		},
+		{
+			testCaseID: 5,
+			tx: func(db *database.Database) transaction.BlockTx {
+				return newTx(t, db, pk, toID, 1, 0, typed(t, "unknown", nil))
+			},
+			from: 1000, expected: "unknown transaction type",
+		},
+	}
+
+	for _, tst := range tt {
+		db := newDB(t, genesis.Genesis{}, map[*ecdsa.PrivateKey]uint64{pk: 1000})
+
+		err := db.ApplyTransaction(b, tst.tx(db))
+
+		switch {
+		case tst.expected == "":
+			if err != nil {
+				t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			}
+
+		case err == nil:
+			t.Errorf("[case:%d] error: expected the transaction to be rejected", tst.testCaseID)
+
+		case !strings.Contains(err.Error(), tst.expected):
+			t.Errorf("[case:%d] error: expected an error about %q got %v", tst.testCaseID, tst.expected, err)
+		}
+
+		// The gas fee is taken even when the transaction fails.
+		got := []amount.Amount{balance(db, id(pk)), balance(db, toID), balance(db, beneficiaryID)}
+		exp := []uint64{tst.from, tst.to, tst.bnfc}
+		for i := range got {
+			if got[i].Cmp(amount.New(exp[i])) != 0 {
+				t.Errorf("[case:%d] error: expected balance %d got %s for account %d", tst.testCaseID, exp[i], got[i], i)
+			}
+		}
+
+		if account, _ := db.Query(id(pk)); account.Nonce != tst.nonce {
+			t.Errorf("[case:%d] error: expected nonce %d got %d", tst.testCaseID, tst.nonce, account.Nonce)
+		}
+	}
+}
+
+// Test_ApplyTransactionBeneficiary checks a miner can send a transaction in
+// its own block. The gas fee and tip it pays itself leave its balance as is.
+func Test_ApplyTransactionBeneficiary(t *testing.T) {
+	pk := chaintest.Key(1)
+	b := block.Block{Header: block.BlockHeader{Number: 3, BeneficiaryID: id(pk)}}
+
+	type table struct {
+		testCaseID int
+		change     func(tx *transaction.Tx)
+		balance    uint64
+		to         uint64
+		nonce      uint64
+		expected   string
+	}
+
+	tt := []table{
+		{testCaseID: 0, balance: 1600, to: 100, nonce: 1},
+		{testCaseID: 1, change: func(tx *transaction.Tx) { tx.Nonce = 2 }, balance: 1700, expected: "wrong nonce"},
+	}
+
+	for _, tst := range tt {
+		db := newDB(t, genesis.Genesis{}, map[*ecdsa.PrivateKey]uint64{pk: 1700})
+
+		err := db.ApplyTransaction(b, newTx(t, db, pk, toID, 100, 5, tst.change))
+
+		switch {
+		case tst.expected == "":
+			if err != nil {
+				t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			}
+
+		case err == nil || !strings.Contains(err.Error(), tst.expected):
+			t.Errorf("[case:%d] error: expected an error about %q got %v", tst.testCaseID, tst.expected, err)
+		}
+
+		account, _ := db.Query(id(pk))
+		if account.Balance.Cmp(amount.New(tst.balance)) != 0 || account.Nonce != tst.nonce {
+			t.Errorf("[case:%d] error: expected balance %d at nonce %d got %s at nonce %d", tst.testCaseID, tst.balance, tst.nonce, account.Balance, account.Nonce)
+		}
+
+		if got := balance(db, toID); got.Cmp(amount.New(tst.to)) != 0 {
+			t.Errorf("[case:%d] error: expected the transfer of %d got %s", tst.testCaseID, tst.to, got)
+		}
+	}
+}
+
+func Test_RotateKey(t *testing.T) {
+	pk := chaintest.Key(1)
+	db := newDB(t, genesis.Genesis{}, map[*ecdsa.PrivateKey]uint64{pk: 1000})
+	b := block.Block{Header: block.BlockHeader{Number: 3, BeneficiaryID: beneficiaryID}}
+
+	rotate := newTx(t, db, pk, toID, 0, 10, typed(t, transaction.TypeRotateKey, nil))
+	if err := db.ApplyTransaction(b, rotate); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	from, _ := db.Query(id(pk))
+	to, _ := db.Query(toID)
+
+	if !from.Balance.IsZero() || from.MigratedTo != toID {
+		t.Errorf("error: expected the balance to move to %s got %s migrated to %s", toID, from.Balance, from.MigratedTo)
+	}
+	if to.Balance.Cmp(amount.New(989)) != 0 || to.MigratedFrom != id(pk) {
+		t.Errorf("error: expected 989 migrated from %s got %s from %s", id(pk), to.Balance, to.MigratedFrom)
+	}
+
+	// The old key can't be used once the account was rotated.
+	err := db.ApplyTransaction(b, newTx(t, db, pk, beneficiaryID, 0, 0, nil))
+	if err == nil || !strings.Contains(err.Error(), "rotated") {
+		t.Errorf("error: expected the rotated account to be rejected got %v", err)
+	}
+
+	// The new account must not be in use.
+	other := chaintest.Key(2)
+	db = newDB(t, genesis.Genesis{}, map[*ecdsa.PrivateKey]uint64{pk: 1000, other: 1})
+	err = db.ApplyTransaction(b, newTx(t, db, pk, id(other), 0, 0, typed(t, transaction.TypeRotateKey, nil)))
+	if err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Errorf("error: expected rotating to an account in use to be rejected got %v", err)
+	}
+}
+
+func Test_ApplyBlock(t *testing.T) {
+	pk := chaintest.Key(1)
+
+	type table struct {
+		testCaseID int
+		shards     uint16
+		change     func(b *block.Block)
+		expected   string
+	}
+
+	tt := []table{
+		{testCaseID: 0, shards: 0, change: func(b *block.Block) {}},
+		{testCaseID: 1, shards: 2, change: func(b *block.Block) {}},
+		{testCaseID: 2, shards: 0, change: func(b *block.Block) { b.Header.StateRoot = "0x00" }, expected: "state root"},
+		{testCaseID: 3, shards: 2, change: func(b *block.Block) { b.Header.ShardRoots = nil }, expected: "shard roots"},
+		{testCaseID: 4, shards: 2, change: func(b *block.Block) { b.Header.ShardRoots[1] = b.Header.ShardRoots[0] }, expected: "shard 1 root"},
+		{testCaseID: 5, shards: 0, change: func(b *block.Block) { b.Header.MiningReward = amount.New(701) }, expected: "mining reward"},
+	}
+
+	for _, tst := range tt {
+		db := newDB(t, genesis.Genesis{Difficulty: 1, MiningReward: amount.New(700), Shards: tst.shards}, map[*ecdsa.PrivateKey]uint64{pk: 1000})
+		before := db.HashState()
+
+		b := candidate(t, db, newTx(t, db, pk, toID, 10, 1, nil))
+		if len(b.Header.ShardRoots) != int(tst.shards) {
+			t.Errorf("[case:%d] error: expected %d shard roots got %d", tst.testCaseID, tst.shards, len(b.Header.ShardRoots))
+		}
+
+		// Executing the block doesn't change the accounts.
+		if db.HashState() != before {
+			t.Errorf("[case:%d] error: expected the state to be unchanged by executing the block", tst.testCaseID)
+		}
+
+		tst.change(&b)
+		err := db.ApplyBlock(chaintest.Mine(t, b))
+
+		if tst.expected == "" {
+			if err != nil {
+				t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+				continue
+			}
+			if db.HashState() != b.Header.StateRoot || db.LatestBlock().Header.Number != 1 {
+				t.Errorf("[case:%d] error: expected block 1 with the state root it claims", tst.testCaseID)
+			}
+			continue
+		}
+
+		if err == nil || !strings.Contains(err.Error(), tst.expected) {
+			t.Errorf("[case:%d] error: expected an error about %q got %v", tst.testCaseID, tst.expected, err)
+		}
+
+		// A rejected block leaves the accounts as they were.
+		if db.HashState() != before || db.LatestBlock().Header.Number != 0 {
+			t.Errorf("[case:%d] error: expected the rejected block to leave the state unchanged", tst.testCaseID)
+		}
+	}
+}
+
+// Test_ApplyBlockWriteFailed checks a block that can't be written to storage
+// leaves the accounts as they were, so the block can be applied once the
+// storage recovers.
+func Test_ApplyBlockWriteFailed(t *testing.T) {
+	pk := chaintest.Key(1)
+
+	gen := genesis.Genesis{
+		ChainID:      1,
+		Difficulty:   1,
+		MiningReward: amount.New(700),
+		Balances:     map[string]amount.Amount{string(id(pk)): amount.New(1000)},
+	}
+
+	store := failingStorage{Memory: memory.New()}
+	db, err := database.New(gen, &store)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	before := db.HashState()
+
+	b := chaintest.Mine(t, candidate(t, db, newTx(t, db, pk, toID, 10, 1, nil)))
+	store.hash = b.Hash()
+
+	if err := db.ApplyBlock(b); err == nil {
+		t.Fatalf("error: expected the write to fail")
+	}
+
+	if db.HashState() != before || db.LatestBlock().Header.Number != 0 {
+		t.Errorf("error: expected the block that wasn't written to leave the state unchanged")
+	}
+	if _, err := db.Rewards(1); err == nil {
+		t.Errorf("error: expected no rewards for the block that wasn't written")
+	}
+
+	store.hash = ""
+	if err := db.ApplyBlock(b); err != nil {
+		t.Errorf("error: expected the block to apply once the storage recovered: %v", err)
+	}
+}
+
+func Test_ExpiredTransaction(t *testing.T) {
+	pk := chaintest.Key(1)
+	db := newDB(t, genesis.Genesis{Difficulty: 1, MiningReward: amount.New(700)}, map[*ecdsa.PrivateKey]uint64{pk: 1000})
+	extend(t, db, pk)
+
+	expired := newTx(t, db, pk, toID, 1, 0, func(tx *transaction.Tx) { tx.ExpiresAt = 1 })
+	before := db.HashState()
+
+	err := db.ApplyBlock(chaintest.Mine(t, candidate(t, db, expired)))
+	if err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("error: expected a block holding an expired transaction to be rejected got %v", err)
+	}
+	if db.HashState() != before {
+		t.Errorf("error: expected the state to be unchanged")
+	}
+}
+
+func Test_Rewards(t *testing.T) {
+	pk := chaintest.Key(1)
+	db := newDB(t, genesis.Genesis{Difficulty: 1, MiningReward: amount.New(700)}, map[*ecdsa.PrivateKey]uint64{pk: 1000})
+
+	first := newTx(t, db, pk, toID, 10, 3, nil)
+	first.GasPrice = amount.New(15)
+
+	// The nonce is too high, the transaction only pays the gas fee.
+	failed := newTx(t, db, pk, toID, 10, 4, func(tx *transaction.Tx) { tx.Nonce = 5 })
+	failed.GasPrice = amount.New(15)
+
+	if err := db.ApplyBlock(chaintest.Mine(t, candidate(t, db, first, failed))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rewards, err := db.Rewards(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := []amount.Amount{rewards.MiningReward, rewards.TotalGasFees, rewards.TotalTips, rewards.Total}
+	exp := []uint64{700, 30, 3, 733}
+	for i := range got {
+		if got[i].Cmp(amount.New(exp[i])) != 0 {
+			t.Errorf("error: expected %d got %s in field %d", exp[i], got[i], i)
+		}
+	}
+
+	var errs int
+	for _, fee := range rewards.TxFees {
+		if fee.Error != "" {
+			errs++
+		}
+	}
+	if errs != 1 {
+		t.Errorf("error: expected 1 failed transaction got %d", errs)
+	}
+
+	if bal := balance(db, beneficiaryID); bal.Cmp(rewards.Total) != 0 {
+		t.Errorf("error: expected the beneficiary to hold %s got %s", rewards.Total, bal)
+	}
+
+	if _, err := db.Rewards(2); err == nil {
+		t.Errorf("error: expected no rewards for a block that doesn't exist")
+	}
+}
+
+func Test_NextGasLimit(t *testing.T) {
+	pk := chaintest.Key(1)
+	db := newDB(t, genesis.Genesis{Difficulty: 1, GasLimit: 2048}, map[*ecdsa.PrivateKey]uint64{pk: 1000})
+
+	type table struct {
+		testCaseID int
+		target     uint64
+		expected   uint64
+	}
+
+	tt := []table{
+		{testCaseID: 0, target: 0, expected: 2048},
+		{testCaseID: 1, target: 5000, expected: 2050},
+		{testCaseID: 2, target: 1, expected: 2046},
+	}
+
+	for _, tst := range tt {
+		if got := db.NextGasLimit(db.LatestBlock(), tst.target); got != tst.expected {
+			t.Errorf("[case:%d] error: expected gas limit %d got %d", tst.testCaseID, tst.expected, got)
+		}
+	}
+
+	// The next block moves on from the limit of its parent.
+	b := candidate(t, db, newTx(t, db, pk, toID, 1, 0, nil))
+	b.Header.GasLimit = db.NextGasLimit(db.LatestBlock(), 5000)
+	if err := db.ApplyBlock(chaintest.Mine(t, b)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := db.NextGasLimit(db.LatestBlock(), 5000); got != 2052 {
+		t.Errorf("error: expected gas limit 2052 got %d", got)
+	}
+}
+
+func Test_Reorganize(t *testing.T) {
+	pk := chaintest.Key(1)
+	gen := genesis.Genesis{Difficulty: 1, MiningReward: amount.New(700)}
+
+	ours := newDB(t, gen, map[*ecdsa.PrivateKey]uint64{pk: 1000})
+	theirs := newDB(t, gen, map[*ecdsa.PrivateKey]uint64{pk: 1000})
+
+	extend(t, ours, pk)
+	extend(t, ours, pk)
+
+	// The fork transfers a different value so its blocks differ.
+	var fork []block.Block
+	for i := 0; i < 3; i++ {
+		b := candidate(t, theirs, newTx(t, theirs, pk, toID, 2, 0, nil))
+		b = chaintest.Mine(t, b)
+		if err := theirs.ApplyBlock(b); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		fork = append(fork, b)
+	}
+
+	badRoot := append([]block.Block{}, fork...)
+	badRoot[2].Header.StateRoot = "0x00"
+	badRoot[2] = chaintest.Mine(t, badRoot[2])
+
+	badHeader := append([]block.Block{}, fork...)
+	badHeader[1].Header.PrevBlockHash = badHeader[0].Header.PrevBlockHash
+
+	type table struct {
+		testCaseID int
+		blocks     []block.Block
+		expected   string
+		err        error
+	}
+
+	tt := []table{
+		{testCaseID: 0, blocks: fork[:2], err: database.ErrForkNotHeavier},
+		{testCaseID: 1, blocks: badHeader, expected: "verifying fork"},
+		{testCaseID: 2, blocks: badRoot, expected: "state root"},
+	}
+
+	hash, state, work := ours.LatestBlock().Hash(), ours.HashState(), ours.ChainWork()
+
+	for _, tst := range tt {
+		err := ours.Reorganize(0, tst.blocks)
+
+		switch {
+		case tst.err != nil:
+			if !errors.Is(err, tst.err) {
+				t.Errorf("[case:%d] error: expected error %v got %v", tst.testCaseID, tst.err, err)
+			}
+
+		case err == nil || !strings.Contains(err.Error(), tst.expected):
+			t.Errorf("[case:%d] error: expected an error about %q got %v", tst.testCaseID, tst.expected, err)
+		}
+
+		// A rejected fork leaves our chain as it was.
+		if ours.LatestBlock().Hash() != hash || ours.HashState() != state || ours.ChainWork().Cmp(work) != 0 {
+			t.Errorf("[case:%d] error: expected the chain to be unchanged", tst.testCaseID)
+		}
+	}
+
+	if err := ours.Reorganize(0, fork); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ours.LatestBlock().Hash() != theirs.LatestBlock().Hash() || ours.HashState() != theirs.HashState() || ours.ChainWork().Cmp(theirs.ChainWork()) != 0 {
+		t.Errorf("error: expected the chain to switch to the heavier fork")
+	}
+	if bal := balance(ours, toID); bal.Cmp(amount.New(6)) != 0 {
+		t.Errorf("error: expected the fork's transfers only got balance %s", bal)
+	}
+}
+
+func Test_Replay(t *testing.T) {
+	pk := chaintest.Key(1)
+	dir := t.TempDir()
+
+	gen := genesis.Genesis{ChainID: 1, Difficulty: 1, MiningReward: amount.New(700), Balances: map[string]amount.Amount{string(id(pk)): amount.New(1000)}}
+
+	store, err := disk.New(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	db, err := database.New(gen, store)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		extend(t, db, pk)
+	}
+
+	hash, state, work := db.LatestBlock().Hash(), db.HashState(), db.ChainWork()
+	if err := db.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	type table struct {
+		testCaseID int
+		balance    uint64
+		success    bool
+	}
+
+	tt := []table{
+		{testCaseID: 0, balance: 1000, success: true},
+
+		// A different genesis doesn't produce the state roots of the blocks.
+		{testCaseID: 1, balance: 999},
+	}
+
+	for _, tst := range tt {
+		store, err := disk.New(dir)
+		if err != nil {
+			t.Fatalf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+
+		g := gen
+		g.Balances = map[string]amount.Amount{string(id(pk)): amount.New(tst.balance)}
+
+		db, err := database.New(g, store)
+
+		if !tst.success {
+			if err == nil || !strings.Contains(err.Error(), "replaying block 1") {
+				t.Errorf("[case:%d] error: expected the replay to fail at block 1 got %v", tst.testCaseID, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+
+		if db.LatestBlock().Hash() != hash || db.HashState() != state || db.ChainWork().Cmp(work) != 0 {
+			t.Errorf("[case:%d] error: expected the replayed chain to match the chain that was closed", tst.testCaseID)
+		}
+		if _, err := db.Rewards(2); err != nil {
+			t.Errorf("[case:%d] error: expected the rewards of replayed blocks: %v", tst.testCaseID, err)
+		}
+
+		db.Close()
+	}
+}
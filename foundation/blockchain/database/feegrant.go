@@ -0,0 +1,84 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+)
+
+// grantFeeModule processes transactions that let the from account pay the
+// gas fees of the to account, up to a limit. The grant can only pay gas
+// fees, the grantee's value and tip always come from its own balance.
+type grantFeeModule struct{}
+
+// Validate implements the Module interface.
+func (grantFeeModule) Validate(tx transaction.BlockTx) error {
+	if _, err := decodeFeeGrant(tx); err != nil {
+		return err
+	}
+
+	if tx.FromID == tx.ToID {
+		return fmt.Errorf("transaction invalid, granting fees to yourself, from %s, to %s", tx.FromID, tx.ToID)
+	}
+
+	if !tx.Value.IsZero() {
+		return fmt.Errorf("transaction invalid, granting fees moves no value, value must be 0, got %s", tx.Value)
+	}
+
+	return nil
+}
+
+// GasUnits implements the Module interface.
+func (grantFeeModule) GasUnits(tx transaction.Tx) uint64 {
+	return 1
+}
+
+// Execute implements the Module interface.
+func (grantFeeModule) Execute(state State, b block.Block, tx transaction.BlockTx) error {
+	data, err := decodeFeeGrant(tx)
+	if err != nil {
+		return err
+	}
+
+	from := state.Account(tx.FromID)
+	from.SetFeeGrant(tx.ToID, data.Limit)
+
+	state.SetAccount(from)
+
+	return nil
+}
+
+// decodeFeeGrant extracts the fee grant data from the transaction.
+func decodeFeeGrant(tx transaction.BlockTx) (transaction.FeeGrantData, error) {
+	var data transaction.FeeGrantData
+	if err := json.Unmarshal(tx.Data, &data); err != nil {
+		return transaction.FeeGrantData{}, fmt.Errorf("transaction invalid, unable to decode fee grant: %w", err)
+	}
+
+	return data, nil
+}
+
+// useFeeGrant takes the gas fee from the granter's grant to the grantee.
+// The granter must have granted enough and hold enough to cover the fee.
+func useFeeGrant(granter *acc.Account, granteeID acc.AccountID, gasFee amount.Amount) error {
+	remaining := granter.FeeGrant(granteeID)
+	if remaining.LessThan(gasFee) {
+		return fmt.Errorf("transaction invalid, fee grant from %s has %s left, needed %s", granter.AccountID, remaining, gasFee)
+	}
+
+	if granter.Balance.LessThan(gasFee) {
+		return fmt.Errorf("transaction invalid, fee granter %s has insufficient funds, bal %s, needed %s", granter.AccountID, granter.Balance, gasFee)
+	}
+
+	remaining, err := remaining.Sub(gasFee)
+	if err != nil {
+		return err
+	}
+	granter.SetFeeGrant(granteeID, remaining)
+
+	return nil
+}
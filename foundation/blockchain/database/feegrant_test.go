@@ -0,0 +1,171 @@
+package database_test
+
+import (
+	"crypto/ecdsa"
+	"strings"
+	"testing"
+
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/chaintest"
+	"github.com/dudakovict/blockchain/foundation/blockchain/database"
+	"github.com/dudakovict/blockchain/foundation/blockchain/genesis"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+)
+
+// Test_FeeGrant applies the steps of a fee grant in order. The granter pays
+// the gas fees of the grantee, who holds no balance, until the grant runs
+// out or is revoked.
+func Test_FeeGrant(t *testing.T) {
+	granter, grantee := chaintest.Key(2), chaintest.Key(3)
+	db := newDB(t, genesis.Genesis{}, map[*ecdsa.PrivateKey]uint64{granter: 1000})
+	b := block.Block{Header: block.BlockHeader{Number: 3, BeneficiaryID: beneficiaryID}}
+
+	grant := func(limit uint64) func(db *database.Database) transaction.BlockTx {
+		return func(db *database.Database) transaction.BlockTx {
+			return newTx(t, db, granter, id(grantee), 0, 0, typed(t, transaction.TypeGrantFee, transaction.FeeGrantData{Limit: amount.New(limit)}))
+		}
+	}
+
+	use := func(value uint64, gasPrice uint64) func(db *database.Database) transaction.BlockTx {
+		return func(db *database.Database) transaction.BlockTx {
+			tx := newTx(t, db, grantee, toID, value, 0, func(tx *transaction.Tx) { tx.FeeGranter = id(granter) })
+			tx.GasPrice = amount.New(gasPrice)
+			return tx
+		}
+	}
+
+	type table struct {
+		testCaseID int
+		tx         func(db *database.Database) transaction.BlockTx
+		expected   string
+		balance    uint64
+		grant      uint64
+		nonce      uint64
+	}
+
+	tt := []table{
+		{testCaseID: 0, tx: grant(3), balance: 999, grant: 3},
+		{testCaseID: 1, tx: use(0, 1), balance: 998, grant: 2, nonce: 1},
+
+		// The grant only pays the gas fee, the value comes from the
+		// grantee's own balance.
+		{testCaseID: 2, tx: use(1, 1), expected: "insufficient funds", balance: 997, grant: 1, nonce: 1},
+
+		// The grant doesn't cover the fee, so the grantee pays what it can.
+		{testCaseID: 3, tx: use(0, 5), expected: "fee grant", balance: 997, grant: 1, nonce: 1},
+
+		// Without the granter the grantee has nothing to pay with.
+		{
+			testCaseID: 4,
+			tx:         func(db *database.Database) transaction.BlockTx { return newTx(t, db, grantee, toID, 0, 0, nil) },
+			expected:   "insufficient funds",
+			balance:    997, grant: 1, nonce: 1,
+		},
+
+		// A limit of zero revokes the grant.
+		{testCaseID: 5, tx: grant(0), balance: 996, grant: 0, nonce: 1},
+		{testCaseID: 6, tx: use(0, 1), expected: "fee grant", balance: 996, grant: 0, nonce: 1},
+	}
+
+	for _, tst := range tt {
+		err := db.ApplyTransaction(b, tst.tx(db))
+
+		switch {
+		case tst.expected == "":
+			if err != nil {
+				t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			}
+
+		case err == nil:
+			t.Errorf("[case:%d] error: expected the transaction to be rejected", tst.testCaseID)
+
+		case !strings.Contains(err.Error(), tst.expected):
+			t.Errorf("[case:%d] error: expected an error about %q got %v", tst.testCaseID, tst.expected, err)
+		}
+
+		account, _ := db.Query(id(granter))
+		if account.Balance.Cmp(amount.New(tst.balance)) != 0 {
+			t.Errorf("[case:%d] error: expected the granter to hold %d got %s", tst.testCaseID, tst.balance, account.Balance)
+		}
+		if got := account.FeeGrant(id(grantee)); got.Cmp(amount.New(tst.grant)) != 0 {
+			t.Errorf("[case:%d] error: expected %d left of the grant got %s", tst.testCaseID, tst.grant, got)
+		}
+
+		if account, _ := db.Query(id(grantee)); account.Nonce != tst.nonce || !account.Balance.IsZero() {
+			t.Errorf("[case:%d] error: expected the grantee at nonce %d with no balance got %d with %s", tst.testCaseID, tst.nonce, account.Nonce, account.Balance)
+		}
+	}
+}
+
+func Test_FeeGrantRejected(t *testing.T) {
+	granter, grantee := chaintest.Key(2), chaintest.Key(3)
+	b := block.Block{Header: block.BlockHeader{Number: 3, BeneficiaryID: beneficiaryID}}
+
+	grant := func(db *database.Database, to *ecdsa.PrivateKey, value uint64) transaction.BlockTx {
+		return newTx(t, db, granter, id(to), value, 0, typed(t, transaction.TypeGrantFee, transaction.FeeGrantData{Limit: amount.New(10)}))
+	}
+
+	type table struct {
+		testCaseID int
+		balance    uint64
+		setup      bool
+		tx         func(db *database.Database) transaction.BlockTx
+		expected   string
+	}
+
+	tt := []table{
+		{
+			testCaseID: 0,
+			balance:    1000,
+			tx:         func(db *database.Database) transaction.BlockTx { return grant(db, granter, 0) },
+			expected:   "yourself",
+		},
+		{
+			testCaseID: 1,
+			balance:    1000,
+			tx:         func(db *database.Database) transaction.BlockTx { return grant(db, grantee, 1) },
+			expected:   "value must be 0",
+		},
+		{
+			testCaseID: 2,
+			balance:    1000,
+			tx: func(db *database.Database) transaction.BlockTx {
+				return newTx(t, db, granter, id(grantee), 0, 0, func(tx *transaction.Tx) {
+					tx.Type = transaction.TypeGrantFee
+					tx.Data = []byte("limit")
+				})
+			},
+			expected: "decode fee grant",
+		},
+
+		// The grant covers the fee but the granter doesn't hold enough.
+		{
+			testCaseID: 3,
+			balance:    2,
+			setup:      true,
+			tx: func(db *database.Database) transaction.BlockTx {
+				tx := newTx(t, db, grantee, toID, 0, 0, func(tx *transaction.Tx) { tx.FeeGranter = id(granter) })
+				tx.GasPrice = amount.New(2)
+				return tx
+			},
+			expected: "fee granter",
+		},
+	}
+
+	for _, tst := range tt {
+		db := newDB(t, genesis.Genesis{}, map[*ecdsa.PrivateKey]uint64{granter: tst.balance})
+
+		if tst.setup {
+			if err := db.ApplyTransaction(b, grant(db, grantee, 0)); err != nil {
+				t.Fatalf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			}
+		}
+
+		err := db.ApplyTransaction(b, tst.tx(db))
+
+		if err == nil || !strings.Contains(err.Error(), tst.expected) {
+			t.Errorf("[case:%d] error: expected an error about %q got %v", tst.testCaseID, tst.expected, err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+)
+
+// PreTxHook represents behavior that is executed before a transaction is
+// applied to the database. Returning an error rejects the transaction before
+// any account is touched, which allows extensions to add their own rules.
+type PreTxHook interface {
+	PreApply(b block.Block, tx transaction.BlockTx) error
+}
+
+// PostTxHook represents behavior that is executed after a transaction has been
+// applied to the database. The error produced by applying the transaction is
+// provided so observers like indexers can record failed transactions as well.
+type PostTxHook interface {
+	PostApply(b block.Block, tx transaction.BlockTx, txErr error)
+}
+
+// PreTxHookFunc is an adapter that allows a function to be used as a PreTxHook.
+type PreTxHookFunc func(b block.Block, tx transaction.BlockTx) error
+
+// PreApply implements the PreTxHook interface.
+func (f PreTxHookFunc) PreApply(b block.Block, tx transaction.BlockTx) error {
+	return f(b, tx)
+}
+
+// PostTxHookFunc is an adapter that allows a function to be used as a PostTxHook.
+type PostTxHookFunc func(b block.Block, tx transaction.BlockTx, txErr error)
+
+// PostApply implements the PostTxHook interface.
+func (f PostTxHookFunc) PostApply(b block.Block, tx transaction.BlockTx, txErr error) {
+	f(b, tx, txErr)
+}
+
+// =============================================================================
+
+// AddPreTxHook registers a hook to be executed before every transaction is
+// applied. Hooks are executed in the order they are registered.
+func (db *Database) AddPreTxHook(hook PreTxHook) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.preTxHooks = append(db.preTxHooks, hook)
+}
+
+// AddPostTxHook registers a hook to be executed after every transaction is
+// applied. Hooks are executed in the order they are registered.
+func (db *Database) AddPostTxHook(hook PostTxHook) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.postTxHooks = append(db.postTxHooks, hook)
+}
+
+// hooks returns a copy of the registered hooks so they can be executed
+// without holding the database lock. This allows hooks to query the database.
+func (db *Database) hooks() ([]PreTxHook, []PostTxHook) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	pre := make([]PreTxHook, len(db.preTxHooks))
+	copy(pre, db.preTxHooks)
+
+	post := make([]PostTxHook, len(db.postTxHooks))
+	copy(post, db.postTxHooks)
+
+	return pre, post
+}
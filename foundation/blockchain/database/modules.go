@@ -0,0 +1,218 @@
+package database
+
+import (
+	"fmt"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+)
+
+// Module represents the behavior required to process a type of transaction.
+// Each transaction type is handled by a single module which keeps features
+// like staking or tokens isolated from the core accounting logic.
+type Module interface {
+
+	// Validate checks the transaction is well formed for this module before
+	// any account is touched.
+	Validate(tx transaction.BlockTx) error
+
+	// GasUnits returns the number of gas units required to process the
+	// transaction when the genesis gas table doesn't specify a cost.
+	GasUnits(tx transaction.Tx) uint64
+
+	// Execute applies the module specific changes of the transaction. The
+	// gas fee, tip and nonce are already handled by the database. The to
+	// account is only touched by modules that use it, so transaction types
+	// that don't name a recipient leave no account behind for it.
+	Execute(state State, b block.Block, tx transaction.BlockTx) error
+}
+
+// State provides modules access to the accounts while a transaction is being
+// applied. Changes are only committed when the module executes successfully.
+type State interface {
+	Account(accountID acc.AccountID) acc.Account
+	SetAccount(account acc.Account)
+}
+
+// RegisterModule adds a module to process transactions of the specified type.
+func (db *Database) RegisterModule(txType string, module Module) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, exists := db.modules[txType]; exists {
+		return fmt.Errorf("module already registered for transaction type %q", txType)
+	}
+
+	db.modules[txType] = module
+	return nil
+}
+
+// GasUnits returns the number of gas units a transaction must pay for. The
+// cost is taken from the genesis gas table for the transaction's type and
+// falls back to the cost defined by the module processing the type. Miners
+// use this value when constructing a block transaction and every node checks
+// it when the transaction is applied.
+func (db *Database) GasUnits(tx transaction.Tx) (uint64, error) {
+	module, err := db.module(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	cost, exists := db.genesis.GasTable[tx.TxType()]
+	if !exists {
+		return module.GasUnits(tx), nil
+	}
+
+	return cost.Base + cost.PerByte*uint64(len(tx.Data)), nil
+}
+
+// module returns the module registered for the transaction's type.
+func (db *Database) module(tx transaction.Tx) (Module, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	module, exists := db.modules[tx.TxType()]
+	if !exists {
+		return nil, fmt.Errorf("transaction invalid, unknown transaction type %q", tx.TxType())
+	}
+
+	return module, nil
+}
+
+// =============================================================================
+
+// pendingState implements the State interface by keeping the changes made by
+// a module separate from the database until they are committed.
+type pendingState struct {
+	accounts    map[acc.AccountID]acc.Account
+	pending     map[acc.AccountID]acc.Account
+	blockNumber uint64
+}
+
+// newPendingState constructs a pending state for the specified accounts
+// while applying a transaction for the specified block.
+func newPendingState(accounts map[acc.AccountID]acc.Account, blockNumber uint64) *pendingState {
+	return &pendingState{
+		accounts:    accounts,
+		pending:     make(map[acc.AccountID]acc.Account),
+		blockNumber: blockNumber,
+	}
+}
+
+// Account returns the current state of the specified account. If the account
+// doesn't exist, a new account with a zero balance is returned.
+func (ps *pendingState) Account(accountID acc.AccountID) acc.Account {
+	if account, exists := ps.pending[accountID]; exists {
+		return account
+	}
+
+	return queryOrNew(ps.accounts, accountID, ps.blockNumber)
+}
+
+// SetAccount records the change to the specified account.
+func (ps *pendingState) SetAccount(account acc.Account) {
+	ps.pending[account.AccountID] = account
+}
+
+// commit applies the pending changes to the accounts.
+func (ps *pendingState) commit() {
+	for accountID, account := range ps.pending {
+		ps.accounts[accountID] = account
+	}
+}
+
+// =============================================================================
+
+// transferModule processes the default transaction type which moves value
+// from one account to another.
+type transferModule struct{}
+
+// Validate implements the Module interface.
+func (transferModule) Validate(tx transaction.BlockTx) error {
+	if tx.FromID == tx.ToID {
+		return fmt.Errorf("transaction invalid, sending money to yourself, from %s, to %s", tx.FromID, tx.ToID)
+	}
+
+	return nil
+}
+
+// GasUnits implements the Module interface.
+func (transferModule) GasUnits(tx transaction.Tx) uint64 {
+	return 1
+}
+
+// Execute implements the Module interface.
+func (transferModule) Execute(state State, b block.Block, tx transaction.BlockTx) error {
+	from := state.Account(tx.FromID)
+	to := state.Account(tx.ToID)
+
+	if err := transfer(&from, &to, tx.Value); err != nil {
+		return err
+	}
+	to.Received++
+
+	state.SetAccount(from)
+	state.SetAccount(to)
+
+	return nil
+}
+
+// =============================================================================
+
+// rotateKeyModule processes transactions that migrate an account to a new
+// key. The remaining balance moves to the new account and both accounts are
+// linked so the old account can no longer send transactions.
+type rotateKeyModule struct{}
+
+// Validate implements the Module interface.
+func (rotateKeyModule) Validate(tx transaction.BlockTx) error {
+	if tx.FromID == tx.ToID {
+		return fmt.Errorf("transaction invalid, rotating key to the same account %s", tx.FromID)
+	}
+
+	if !tx.Value.IsZero() {
+		return fmt.Errorf("transaction invalid, rotating key moves the full balance, value must be 0, got %s", tx.Value)
+	}
+
+	return nil
+}
+
+// GasUnits implements the Module interface.
+func (rotateKeyModule) GasUnits(tx transaction.Tx) uint64 {
+	return 1
+}
+
+// Execute implements the Module interface.
+func (rotateKeyModule) Execute(state State, b block.Block, tx transaction.BlockTx) error {
+	return migrateAccount(state, tx.FromID, tx.ToID)
+}
+
+// migrateAccount moves the balance and guardians of an account to a new
+// unused account and links the two accounts in the state.
+func migrateAccount(state State, fromID acc.AccountID, toID acc.AccountID) error {
+	from := state.Account(fromID)
+	to := state.Account(toID)
+
+	if to.Nonce != 0 || !to.Balance.IsZero() || to.MigratedFrom != "" {
+		return fmt.Errorf("transaction invalid, account %s is already in use", toID)
+	}
+
+	to.Balance = from.Balance
+	to.Guardians = from.Guardians
+	to.Threshold = from.Threshold
+	to.MigratedFrom = from.AccountID
+	to.Received++
+
+	from.Balance = amount.Amount{}
+	from.Guardians = nil
+	from.Threshold = 0
+	from.Recovery = nil
+	from.MigratedTo = to.AccountID
+
+	state.SetAccount(from)
+	state.SetAccount(to)
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+)
+
+// setGuardiansModule processes transactions that register the guardians of
+// an account. Registering guardians also cancels any recovery in progress,
+// which lets the owner stop a recovery during the delay.
+type setGuardiansModule struct{}
+
+// Validate implements the Module interface.
+func (setGuardiansModule) Validate(tx transaction.BlockTx) error {
+	data, err := decodeGuardians(tx)
+	if err != nil {
+		return err
+	}
+
+	if int(data.Threshold) > len(data.Guardians) {
+		return fmt.Errorf("transaction invalid, threshold %d is more than the %d guardians", data.Threshold, len(data.Guardians))
+	}
+
+	if len(data.Guardians) > 0 && data.Threshold == 0 {
+		return errors.New("transaction invalid, threshold must be at least 1")
+	}
+
+	unique := make(map[acc.AccountID]bool)
+	for _, guardian := range data.Guardians {
+		if !guardian.IsAccountID() {
+			return fmt.Errorf("transaction invalid, guardian %s is not properly formatted", guardian)
+		}
+
+		if guardian == tx.FromID {
+			return errors.New("transaction invalid, an account can't be its own guardian")
+		}
+
+		if unique[guardian] {
+			return fmt.Errorf("transaction invalid, guardian %s is listed twice", guardian)
+		}
+		unique[guardian] = true
+	}
+
+	return nil
+}
+
+// GasUnits implements the Module interface.
+func (setGuardiansModule) GasUnits(tx transaction.Tx) uint64 {
+	return 1
+}
+
+// Execute implements the Module interface.
+func (setGuardiansModule) Execute(state State, b block.Block, tx transaction.BlockTx) error {
+	data, err := decodeGuardians(tx)
+	if err != nil {
+		return err
+	}
+
+	from := state.Account(tx.FromID)
+	from.Guardians = data.Guardians
+	from.Threshold = data.Threshold
+	from.Recovery = nil
+
+	state.SetAccount(from)
+
+	return nil
+}
+
+// decodeGuardians extracts the guardians data from the transaction.
+func decodeGuardians(tx transaction.BlockTx) (transaction.GuardiansData, error) {
+	var data transaction.GuardiansData
+	if err := json.Unmarshal(tx.Data, &data); err != nil {
+		return transaction.GuardiansData{}, fmt.Errorf("transaction invalid, unable to decode guardians: %w", err)
+	}
+
+	return data, nil
+}
+
+// =============================================================================
+
+// recoverAccountModule processes the approvals of guardians to recover an
+// account to a new key. Once enough guardians have approved and the delay
+// has passed, the next approval migrates the account to the new key.
+type recoverAccountModule struct {
+	delay uint64
+}
+
+// Validate implements the Module interface.
+func (recoverAccountModule) Validate(tx transaction.BlockTx) error {
+	data, err := decodeRecovery(tx)
+	if err != nil {
+		return err
+	}
+
+	if !data.NewID.IsAccountID() {
+		return errors.New("transaction invalid, new account is not properly formatted")
+	}
+
+	if data.NewID == tx.ToID {
+		return fmt.Errorf("transaction invalid, recovering account to itself %s", tx.ToID)
+	}
+
+	if !tx.Value.IsZero() {
+		return fmt.Errorf("transaction invalid, recovering an account can't transfer value, got %s", tx.Value)
+	}
+
+	return nil
+}
+
+// GasUnits implements the Module interface.
+func (recoverAccountModule) GasUnits(tx transaction.Tx) uint64 {
+	return 1
+}
+
+// Execute implements the Module interface.
+func (m recoverAccountModule) Execute(state State, b block.Block, tx transaction.BlockTx) error {
+	data, err := decodeRecovery(tx)
+	if err != nil {
+		return err
+	}
+
+	account := state.Account(tx.ToID)
+
+	if !account.IsGuardian(tx.FromID) {
+		return fmt.Errorf("transaction invalid, %s is not a guardian of %s", tx.FromID, tx.ToID)
+	}
+
+	if account.MigratedTo != "" {
+		return fmt.Errorf("transaction invalid, account key was rotated to %s", account.MigratedTo)
+	}
+
+	recovery := acc.Recovery{
+		NewID:     data.NewID,
+		StartedAt: b.Header.Number,
+	}
+
+	if account.Recovery != nil {
+		if account.Recovery.NewID != data.NewID {
+			return fmt.Errorf("transaction invalid, recovery to %s already in progress", account.Recovery.NewID)
+		}
+		recovery.StartedAt = account.Recovery.StartedAt
+		recovery.Approvals = append(recovery.Approvals, account.Recovery.Approvals...)
+	}
+
+	approved := false
+	for _, approval := range recovery.Approvals {
+		if approval == tx.FromID {
+			approved = true
+			break
+		}
+	}
+	if !approved {
+		recovery.Approvals = append(recovery.Approvals, tx.FromID)
+	}
+
+	// Perform the recovery once enough guardians approved and the owner
+	// had the full delay to cancel it.
+	if len(recovery.Approvals) >= int(account.Threshold) && b.Header.Number >= recovery.StartedAt+m.delay {
+		return migrateAccount(state, account.AccountID, recovery.NewID)
+	}
+
+	account.Recovery = &recovery
+	state.SetAccount(account)
+
+	return nil
+}
+
+// decodeRecovery extracts the recovery data from the transaction.
+func decodeRecovery(tx transaction.BlockTx) (transaction.RecoveryData, error) {
+	var data transaction.RecoveryData
+	if err := json.Unmarshal(tx.Data, &data); err != nil {
+		return transaction.RecoveryData{}, fmt.Errorf("transaction invalid, unable to decode recovery: %w", err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,206 @@
+package database_test
+
+import (
+	"crypto/ecdsa"
+	"strings"
+	"testing"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/chaintest"
+	"github.com/dudakovict/blockchain/foundation/blockchain/database"
+	"github.com/dudakovict/blockchain/foundation/blockchain/genesis"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+)
+
+// Test_Recovery applies the steps of an account recovery in order. Two of
+// three guardians must approve and the owner has 2 blocks to cancel before
+// the account moves to the new key.
+func Test_Recovery(t *testing.T) {
+	owner, outsider := chaintest.Key(1), chaintest.Key(7)
+	g1, g2, g3 := chaintest.Key(4), chaintest.Key(5), chaintest.Key(6)
+	newID, otherID := id(chaintest.Key(8)), id(chaintest.Key(9))
+
+	balances := map[*ecdsa.PrivateKey]uint64{owner: 1000, outsider: 10, g1: 10, g2: 10, g3: 10}
+	db := newDB(t, genesis.Genesis{RecoveryDelay: 2}, balances)
+
+	guard := func(db *database.Database) transaction.BlockTx {
+		data := transaction.GuardiansData{Guardians: []acc.AccountID{id(g1), id(g2), id(g3)}, Threshold: 2}
+		return newTx(t, db, owner, id(owner), 0, 0, typed(t, transaction.TypeSetGuardians, data))
+	}
+
+	approve := func(guardian *ecdsa.PrivateKey, to acc.AccountID) func(db *database.Database) transaction.BlockTx {
+		return func(db *database.Database) transaction.BlockTx {
+			return newTx(t, db, guardian, id(owner), 0, 0, typed(t, transaction.TypeRecoverAccount, transaction.RecoveryData{NewID: to}))
+		}
+	}
+
+	type table struct {
+		testCaseID int
+		number     uint64
+		tx         func(db *database.Database) transaction.BlockTx
+		expected   string
+		approvals  int
+		migrated   bool
+	}
+
+	tt := []table{
+		{testCaseID: 0, number: 4, tx: guard},
+		{testCaseID: 1, number: 5, tx: approve(g1, newID), approvals: 1},
+		{testCaseID: 2, number: 5, tx: approve(outsider, newID), expected: "not a guardian", approvals: 1},
+		{testCaseID: 3, number: 5, tx: approve(g2, otherID), expected: "already in progress", approvals: 1},
+
+		// Enough guardians approved but the delay hasn't passed.
+		{testCaseID: 4, number: 6, tx: approve(g2, newID), approvals: 2},
+		{testCaseID: 5, number: 6, tx: approve(g2, newID), approvals: 2},
+
+		// The next approval after the delay performs the recovery.
+		{testCaseID: 6, number: 7, tx: approve(g1, newID), migrated: true},
+		{
+			testCaseID: 7,
+			number:     8,
+			tx:         func(db *database.Database) transaction.BlockTx { return newTx(t, db, owner, toID, 1, 0, nil) },
+			expected:   "rotated",
+			migrated:   true,
+		},
+		{testCaseID: 8, number: 8, tx: approve(g3, otherID), expected: "not a guardian", migrated: true},
+	}
+
+	for _, tst := range tt {
+		b := block.Block{Header: block.BlockHeader{Number: tst.number, BeneficiaryID: beneficiaryID}}
+		err := db.ApplyTransaction(b, tst.tx(db))
+
+		switch {
+		case tst.expected == "":
+			if err != nil {
+				t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			}
+
+		case err == nil:
+			t.Errorf("[case:%d] error: expected the transaction to be rejected", tst.testCaseID)
+
+		case !strings.Contains(err.Error(), tst.expected):
+			t.Errorf("[case:%d] error: expected an error about %q got %v", tst.testCaseID, tst.expected, err)
+		}
+
+		account, _ := db.Query(id(owner))
+
+		if !tst.migrated {
+			var approvals int
+			if account.Recovery != nil {
+				approvals = len(account.Recovery.Approvals)
+			}
+			if approvals != tst.approvals || account.MigratedTo != "" {
+				t.Errorf("[case:%d] error: expected %d approvals got %d", tst.testCaseID, tst.approvals, approvals)
+			}
+			continue
+		}
+
+		if account.MigratedTo != newID || !account.Balance.IsZero() || account.Recovery != nil || len(account.Guardians) != 0 {
+			t.Errorf("[case:%d] error: expected the account to be migrated to %s got %+v", tst.testCaseID, newID, account)
+		}
+
+		// The owner only paid the gas fee to set the guardians.
+		recovered, _ := db.Query(newID)
+		if recovered.Balance.Cmp(amount.New(999)) != 0 || recovered.MigratedFrom != id(owner) || recovered.Threshold != 2 || len(recovered.Guardians) != 3 {
+			t.Errorf("[case:%d] error: expected the recovered account to hold 999 and the guardians got %+v", tst.testCaseID, recovered)
+		}
+	}
+}
+
+// Test_RecoveryCancel checks the owner cancels a recovery by setting the
+// guardians again and the delay starts over with the next approval.
+func Test_RecoveryCancel(t *testing.T) {
+	owner, g1, g2 := chaintest.Key(1), chaintest.Key(4), chaintest.Key(5)
+	newID := id(chaintest.Key(8))
+
+	db := newDB(t, genesis.Genesis{RecoveryDelay: 2}, map[*ecdsa.PrivateKey]uint64{owner: 1000, g1: 10, g2: 10})
+
+	apply := func(number uint64, tx transaction.BlockTx) {
+		t.Helper()
+
+		b := block.Block{Header: block.BlockHeader{Number: number, BeneficiaryID: beneficiaryID}}
+		if err := db.ApplyTransaction(b, tx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	guard := func() transaction.BlockTx {
+		data := transaction.GuardiansData{Guardians: []acc.AccountID{id(g1), id(g2)}, Threshold: 1}
+		return newTx(t, db, owner, id(owner), 0, 0, typed(t, transaction.TypeSetGuardians, data))
+	}
+
+	approve := func(guardian *ecdsa.PrivateKey) transaction.BlockTx {
+		return newTx(t, db, guardian, id(owner), 0, 0, typed(t, transaction.TypeRecoverAccount, transaction.RecoveryData{NewID: newID}))
+	}
+
+	apply(1, guard())
+	apply(2, approve(g1))
+	apply(3, guard())
+
+	account, _ := db.Query(id(owner))
+	if account.Recovery != nil {
+		t.Fatalf("error: expected setting the guardians to cancel the recovery got %+v", account.Recovery)
+	}
+
+	// Block 4 would have completed the first recovery.
+	apply(4, approve(g2))
+	apply(5, approve(g2))
+
+	account, _ = db.Query(id(owner))
+	if account.Recovery == nil || account.Recovery.StartedAt != 4 || account.MigratedTo != "" {
+		t.Fatalf("error: expected a recovery started at block 4 got %+v", account.Recovery)
+	}
+
+	apply(6, approve(g2))
+
+	if account, _ := db.Query(id(owner)); account.MigratedTo != newID {
+		t.Errorf("error: expected the account to be migrated to %s at block 6", newID)
+	}
+}
+
+func Test_RecoveryRejected(t *testing.T) {
+	owner, g1, g2 := chaintest.Key(1), chaintest.Key(4), chaintest.Key(5)
+	b := block.Block{Header: block.BlockHeader{Number: 3, BeneficiaryID: beneficiaryID}}
+
+	guard := func(threshold uint16, guardians ...acc.AccountID) func(db *database.Database) transaction.BlockTx {
+		return func(db *database.Database) transaction.BlockTx {
+			data := transaction.GuardiansData{Guardians: guardians, Threshold: threshold}
+			return newTx(t, db, owner, id(owner), 0, 0, typed(t, transaction.TypeSetGuardians, data))
+		}
+	}
+
+	approve := func(to acc.AccountID, value uint64) func(db *database.Database) transaction.BlockTx {
+		return func(db *database.Database) transaction.BlockTx {
+			return newTx(t, db, g1, id(owner), value, 0, typed(t, transaction.TypeRecoverAccount, transaction.RecoveryData{NewID: to}))
+		}
+	}
+
+	type table struct {
+		testCaseID int
+		tx         func(db *database.Database) transaction.BlockTx
+		expected   string
+	}
+
+	tt := []table{
+		{testCaseID: 0, tx: guard(3, id(g1), id(g2)), expected: "threshold 3"},
+		{testCaseID: 1, tx: guard(0, id(g1), id(g2)), expected: "at least 1"},
+		{testCaseID: 2, tx: guard(1, id(g1), id(owner)), expected: "own guardian"},
+		{testCaseID: 3, tx: guard(1, id(g1), id(g1)), expected: "listed twice"},
+		{testCaseID: 4, tx: guard(1, "0x1234"), expected: "not properly formatted"},
+		{testCaseID: 5, tx: approve("0x1234", 0), expected: "not properly formatted"},
+		{testCaseID: 6, tx: approve(id(owner), 0), expected: "to itself"},
+		{testCaseID: 7, tx: approve(id(chaintest.Key(8)), 1), expected: "transfer value"},
+	}
+
+	for _, tst := range tt {
+		db := newDB(t, genesis.Genesis{}, map[*ecdsa.PrivateKey]uint64{owner: 1000, g1: 10})
+
+		err := db.ApplyTransaction(b, tst.tx(db))
+
+		if err == nil || !strings.Contains(err.Error(), tst.expected) {
+			t.Errorf("[case:%d] error: expected an error about %q got %v", tst.testCaseID, tst.expected, err)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+// Package datadir owns the on-disk layout of a node's data directory and
+// makes sure only one process uses a data directory at a time.
+//
+//	<root>/
+//	    LOCK        held by the process using the directory
+//	    chain/      the blocks
+//	    state/      state kept between runs
+//	        blocks.wal  the blocks not yet committed to chain/
+//	    keystore/   account keys
+//	    nodekey     the node's private key
+//	    peers.json  the peers known when the node last stopped
+package datadir
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrLocked is returned when another process is using the data directory.
+var ErrLocked = errors.New("data directory is in use by another process")
+
+// Set of names that make up the layout of the data directory.
+const (
+	lockName     = "LOCK"
+	chainName    = "chain"
+	stateName    = "state"
+	keystoreName = "keystore"
+	nodeKeyName  = "nodekey"
+	peersName    = "peers.json"
+	blockWALName = "blocks.wal"
+)
+
+// Permissions for the directories and files. Everything is private to the
+// user running the node since the directory holds private keys.
+const (
+	dirPerm  = 0700
+	filePerm = 0600
+)
+
+// DataDir represents an open data directory.
+type DataDir struct {
+	root string
+	lock *os.File
+}
+
+// Open creates the data directory layout if required and locks the
+// directory for this process. ErrLocked is returned if another process
+// has the directory open.
+func Open(root string) (*DataDir, error) {
+	if err := os.MkdirAll(root, dirPerm); err != nil {
+		return nil, fmt.Errorf("creating data directory: %w", err)
+	}
+
+	lock, err := lockFile(filepath.Join(root, lockName))
+	if err != nil {
+		if errors.Is(err, ErrLocked) {
+			return nil, fmt.Errorf("%s: %w", root, ErrLocked)
+		}
+		return nil, fmt.Errorf("locking data directory: %w", err)
+	}
+
+	d := DataDir{
+		root: root,
+		lock: lock,
+	}
+
+	for _, dir := range []string{d.ChainDir(), d.StateDir(), d.KeystoreDir()} {
+		if err := os.MkdirAll(dir, dirPerm); err != nil {
+			d.Close()
+			return nil, fmt.Errorf("creating data directory: %w", err)
+		}
+	}
+
+	return &d, nil
+}
+
+// Close releases the lock on the data directory.
+func (d *DataDir) Close() error {
+	return unlockFile(d.lock)
+}
+
+// Root returns the location of the data directory.
+func (d *DataDir) Root() string {
+	return d.root
+}
+
+// ChainDir returns the directory the blocks are stored in.
+func (d *DataDir) ChainDir() string {
+	return filepath.Join(d.root, chainName)
+}
+
+// StateDir returns the directory for state kept between runs.
+func (d *DataDir) StateDir() string {
+	return filepath.Join(d.root, stateName)
+}
+
+// BlockWAL returns the path of the write-ahead log holding the blocks that
+// haven't been committed to the chain directory yet.
+func (d *DataDir) BlockWAL() string {
+	return filepath.Join(d.StateDir(), blockWALName)
+}
+
+// KeystoreDir returns the directory the account keys are stored in.
+func (d *DataDir) KeystoreDir() string {
+	return filepath.Join(d.root, keystoreName)
+}
+
+// =============================================================================
+
+// NodeKey returns the node's private key. A new key is generated the first
+// time the data directory is used.
+func (d *DataDir) NodeKey() (*ecdsa.PrivateKey, error) {
+	file := filepath.Join(d.root, nodeKeyName)
+
+	privateKey, err := crypto.LoadECDSA(file)
+	if err == nil {
+		return privateKey, nil
+	}
+
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("loading node key: %w", err)
+	}
+
+	if privateKey, err = crypto.GenerateKey(); err != nil {
+		return nil, err
+	}
+
+	if err := crypto.SaveECDSA(file, privateKey); err != nil {
+		return nil, fmt.Errorf("saving node key: %w", err)
+	}
+
+	return privateKey, nil
+}
+
+// LoadPeers returns the hosts of the peers saved when the node last
+// stopped. No hosts are returned if the peers were never saved.
+func (d *DataDir) LoadPeers() ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(d.root, peersName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var hosts []string
+	if err := json.Unmarshal(data, &hosts); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", peersName, err)
+	}
+
+	return hosts, nil
+}
+
+// SavePeers saves the hosts of the known peers so the node can reconnect
+// to them when it starts again.
+func (d *DataDir) SavePeers(hosts []string) error {
+	data, err := json.MarshalIndent(hosts, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	return writeFile(filepath.Join(d.root, peersName), data)
+}
+
+// =============================================================================
+
+// writeFile replaces the file through a temporary file so a crash never
+// leaves a partially written file behind.
+func writeFile(file string, data []byte) error {
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, filePerm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, file)
+}
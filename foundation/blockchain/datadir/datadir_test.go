@@ -0,0 +1,104 @@
+package datadir_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dudakovict/blockchain/foundation/blockchain/datadir"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func Test_Open(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "node")
+
+	d, err := datadir.Open(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, dir := range []string{d.ChainDir(), d.StateDir(), d.KeystoreDir()} {
+		info, err := os.Stat(dir)
+		if err != nil || !info.IsDir() {
+			t.Errorf("error: expected the directory %s to be created: %v", dir, err)
+		}
+	}
+
+	if filepath.Dir(d.BlockWAL()) != d.StateDir() {
+		t.Errorf("error: expected the block log under %s got %s", d.StateDir(), d.BlockWAL())
+	}
+
+	// A second process can't use the directory until the first closes it.
+	if _, err := datadir.Open(root); !errors.Is(err, datadir.ErrLocked) {
+		t.Errorf("error: expected %v got %v", datadir.ErrLocked, err)
+	}
+
+	if err := d.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	d, err = datadir.Open(root)
+	if err != nil {
+		t.Fatalf("error: expected to open the directory once it was closed: %v", err)
+	}
+	d.Close()
+}
+
+func Test_NodeKey(t *testing.T) {
+	root := t.TempDir()
+
+	d, err := datadir.Open(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer d.Close()
+
+	first, err := d.NodeKey()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	second, err := d.NodeKey()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if crypto.PubkeyToAddress(first.PublicKey) != crypto.PubkeyToAddress(second.PublicKey) {
+		t.Errorf("error: expected the same node key every time the directory is used")
+	}
+
+	info, err := os.Stat(filepath.Join(root, "nodekey"))
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("error: expected the node key to be private to the user: %v", err)
+	}
+}
+
+func Test_Peers(t *testing.T) {
+	d, err := datadir.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer d.Close()
+
+	hosts, err := d.LoadPeers()
+	if err != nil || len(hosts) != 0 {
+		t.Fatalf("error: expected no peers before any were saved got %v: %v", hosts, err)
+	}
+
+	if err := d.SavePeers([]string{"0.0.0.0:9080", "0.0.0.0:9180"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	hosts, err = d.LoadPeers()
+	if err != nil || len(hosts) != 2 || hosts[1] != "0.0.0.0:9180" {
+		t.Errorf("error: expected the saved peers got %v: %v", hosts, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(d.Root(), "peers.json"), []byte("[0.0.0.0"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := d.LoadPeers(); err == nil {
+		t.Errorf("error: expected a damaged peers file to be rejected")
+	}
+}
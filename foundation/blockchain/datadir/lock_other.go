@@ -0,0 +1,32 @@
+//go:build !unix
+
+package datadir
+
+import (
+	"errors"
+	"os"
+)
+
+// lockFile creates the file, failing if it already exists. Unlike the unix
+// lock, the file is left behind if the process dies and has to be removed
+// by hand before the data directory can be opened again.
+func lockFile(file string) (*os.File, error) {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_EXCL|os.O_RDWR, filePerm)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return nil, ErrLocked
+		}
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// unlockFile closes and removes the file.
+func unlockFile(f *os.File) error {
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(f.Name())
+}
@@ -3,26 +3,76 @@ package genesis
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"time"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+	"github.com/dudakovict/blockchain/foundation/blockchain/proof"
+	"github.com/dudakovict/blockchain/foundation/blockchain/signature"
 )
 
 // Genesis represents the genesis file.
 type Genesis struct {
-	Date          time.Time         `json:"date"`
-	ChainID       uint16            `json:"chain_id"`
-	TransPerBlock uint16            `json:"trans_per_block"`
-	Difficulty    uint16            `json:"difficulty"`
-	MiningReward  uint64            `json:"mining_reward"`
-	GasPrice      uint64            `json:"gas_price"`
-	Balances      map[string]uint64 `json:"balances"`
+	Date           time.Time                `json:"date"`
+	ChainID        uint16                   `json:"chain_id"`
+	Consensus      string                   `json:"consensus"`   // Either "pow", the default, or "poa".
+	Authorities    []acc.AccountID          `json:"authorities"` // Accounts that take turns signing blocks under "poa".
+	TransPerBlock  uint16                   `json:"trans_per_block"`
+	Difficulty     uint16                   `json:"difficulty"`      // Difficulty of the first block, adjusted over time when a block interval is set.
+	BlockInterval  uint64                   `json:"block_interval"`  // Target number of seconds between blocks, zero keeps the difficulty fixed.
+	RetargetBlocks uint64                   `json:"retarget_blocks"` // Number of blocks between difficulty adjustments.
+	MiningReward   amount.Amount            `json:"mining_reward"`
+	RewardForks    []RewardFork             `json:"reward_forks"`
+	GasPrice       amount.Amount            `json:"gas_price"`
+	GasLimit       uint64                   `json:"gas_limit"` // Initial gas limit for blocks, adjusted by miners over time.
+	GasTable       map[string]GasCost       `json:"gas_table"`
+	Ordering       string                   `json:"ordering_policy"`
+	Shards         uint16                   `json:"shards"`         // Experimental: Number of shards accounts are partitioned into.
+	RecoveryDelay  uint64                   `json:"recovery_delay"` // Number of blocks guardians must wait to recover an account.
+	Balances       map[string]amount.Amount `json:"balances"`
+}
+
+// GasCost represents the gas units charged for a type of transaction. The
+// per byte cost is charged for the size of the transaction's data payload.
+type GasCost struct {
+	Base    uint64 `json:"base"`
+	PerByte uint64 `json:"per_byte"`
+}
+
+// RewardFork represents a hardfork that changes the mining reward for every
+// block starting at the specified block number.
+type RewardFork struct {
+	Number       uint64        `json:"number"`
+	MiningReward amount.Amount `json:"mining_reward"`
+}
+
+// MiningRewardAt returns the mining reward a block with the specified number
+// must claim. The reward is the genesis reward unless a reward fork has been
+// activated at or before the block number.
+func (g Genesis) MiningRewardAt(number uint64) amount.Amount {
+	reward := g.MiningReward
+
+	var activated uint64
+	for _, fork := range g.RewardForks {
+		if fork.Number <= number && fork.Number >= activated {
+			reward = fork.MiningReward
+			activated = fork.Number
+		}
+	}
+
+	return reward
 }
 
 // =============================================================================
 
-// Load opens and consumes the genesis file.
-func Load() (Genesis, error) {
-	path := "zblock/genesis.json"
+// DefaultPath is the location of the genesis file the node and the tooling
+// use unless told otherwise.
+const DefaultPath = "zblock/genesis.json"
+
+// Load opens and consumes the genesis file at the specified path.
+func Load(path string) (Genesis, error) {
 	content, err := os.ReadFile(path)
 	if err != nil {
 		return Genesis{}, err
@@ -34,5 +84,48 @@ func Load() (Genesis, error) {
 		return Genesis{}, err
 	}
 
+	// Transactions are signed for a chain id, so a chain without one would
+	// accept transactions replayed from any other chain left at the default.
+	if genesis.ChainID == 0 {
+		return Genesis{}, errors.New("genesis chain_id is missing")
+	}
+
+	consensus, err := proof.ToConsensus(genesis.Consensus)
+	if err != nil {
+		return Genesis{}, err
+	}
+
+	if consensus == proof.ConsensusPOA && len(genesis.Authorities) == 0 {
+		return Genesis{}, errors.New("genesis authorities are required for poa consensus")
+	}
+
 	return genesis, nil
 }
+
+// Hash returns the hash of the genesis settings. Nodes running the same
+// chain have the same genesis hash.
+func (g Genesis) Hash() string {
+	return signature.Hash(g)
+}
+
+// ChainRules returns the settings from genesis the blocks of the chain are
+// validated against.
+func (g Genesis) ChainRules() (proof.ChainRules, error) {
+	consensus, err := proof.ToConsensus(g.Consensus)
+	if err != nil {
+		return proof.ChainRules{}, err
+	}
+
+	rules := proof.ChainRules{
+		Consensus: consensus,
+		Retarget: proof.Retarget{
+			Difficulty:    g.Difficulty,
+			BlockInterval: time.Duration(g.BlockInterval) * time.Second,
+			Blocks:        g.RetargetBlocks,
+		},
+		Authorities: g.Authorities,
+		GasLimit:    g.GasLimit,
+	}
+
+	return rules, nil
+}
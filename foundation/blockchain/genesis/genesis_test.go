@@ -0,0 +1,126 @@
+package genesis_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+	"github.com/dudakovict/blockchain/foundation/blockchain/genesis"
+	"github.com/dudakovict/blockchain/foundation/blockchain/proof"
+)
+
+func Test_Load(t *testing.T) {
+	type table struct {
+		testCaseID int
+		content    string
+		success    bool
+	}
+
+	tt := []table{
+		{testCaseID: 0, content: `{"chain_id": 1, "difficulty": 6, "mining_reward": 700}`, success: true},
+		{testCaseID: 1, content: `{"chain_id": 1, "consensus": "poa", "authorities": ["0xFef311483Cc040e1A89fb9bb469eeB8A70935EF8"]}`, success: true},
+		{testCaseID: 2, content: `{"difficulty": 6}`},
+		{testCaseID: 3, content: `{"chain_id": 1, "consensus": "poa"}`},
+		{testCaseID: 4, content: `{"chain_id": 1, "consensus": "pos"}`},
+		{testCaseID: 5, content: `{"chain_id": 1,`},
+	}
+
+	for _, tst := range tt {
+		path := filepath.Join(t.TempDir(), "genesis.json")
+		if err := os.WriteFile(path, []byte(tst.content), 0600); err != nil {
+			t.Fatalf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+
+		gen, err := genesis.Load(path)
+
+		if !tst.success {
+			if err == nil {
+				t.Errorf("[case:%d] error: expected %s to be rejected", tst.testCaseID, tst.content)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+		if gen.ChainID != 1 {
+			t.Errorf("[case:%d] error: expected chain id 1 got %d", tst.testCaseID, gen.ChainID)
+		}
+	}
+
+	if _, err := genesis.Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Errorf("error: expected a missing file to fail")
+	}
+}
+
+func Test_ChainRules(t *testing.T) {
+	gen := genesis.Genesis{Consensus: "", Difficulty: 4, BlockInterval: 12, RetargetBlocks: 10, GasLimit: 2048}
+
+	rules, err := gen.ChainRules()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if rules.Consensus != proof.ConsensusPOW {
+		t.Errorf("error: expected %s consensus got %s", proof.ConsensusPOW, rules.Consensus)
+	}
+	if rules.Retarget.Difficulty != 4 || rules.Retarget.BlockInterval != 12*time.Second || rules.Retarget.Blocks != 10 {
+		t.Errorf("error: expected the retarget settings from genesis got %+v", rules.Retarget)
+	}
+	if rules.GasLimit != 2048 {
+		t.Errorf("error: expected gas limit 2048 got %d", rules.GasLimit)
+	}
+
+	gen.Consensus = "pos"
+	if _, err := gen.ChainRules(); err == nil {
+		t.Errorf("error: expected an unknown consensus to fail")
+	}
+}
+
+func Test_Hash(t *testing.T) {
+	gen := genesis.Genesis{ChainID: 1, Difficulty: 4}
+
+	if gen.Hash() != gen.Hash() {
+		t.Errorf("error: expected the same hash for the same genesis")
+	}
+
+	other := gen
+	other.ChainID = 2
+	if other.Hash() == gen.Hash() {
+		t.Errorf("error: expected a different hash for another chain")
+	}
+}
+
+func Test_MiningRewardAt(t *testing.T) {
+	gen := genesis.Genesis{
+		MiningReward: amount.New(700),
+		RewardForks: []genesis.RewardFork{
+			{Number: 200, MiningReward: amount.New(175)},
+			{Number: 100, MiningReward: amount.New(350)},
+		},
+	}
+
+	type table struct {
+		testCaseID int
+		number     uint64
+		expected   uint64
+	}
+
+	tt := []table{
+		{testCaseID: 0, number: 1, expected: 700},
+		{testCaseID: 1, number: 99, expected: 700},
+		{testCaseID: 2, number: 100, expected: 350},
+		{testCaseID: 3, number: 199, expected: 350},
+		{testCaseID: 4, number: 200, expected: 175},
+		{testCaseID: 5, number: 1_000_000, expected: 175},
+	}
+
+	for _, tst := range tt {
+		if got := gen.MiningRewardAt(tst.number); got.Cmp(amount.New(tst.expected)) != 0 {
+			t.Errorf("[case:%d] error: expected reward %d at block %d got %s", tst.testCaseID, tst.expected, tst.number, got)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+// Package mempool maintains the mempool for the blockchain.
+package mempool
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+)
+
+// Content represents the transactions of an account waiting in the mempool.
+// Pending transactions can be included in the next block, queued ones wait
+// for a transaction with a lower nonce that isn't in the mempool.
+type Content struct {
+	Pending []transaction.BlockTx
+	Queued  []transaction.BlockTx
+}
+
+// Mempool represents a cache of transactions organized by account:nonce.
+type Mempool struct {
+	mu      sync.RWMutex
+	chainID uint16
+	pool    map[string]transaction.BlockTx
+}
+
+// New constructs a new mempool to manage pending transactions for the
+// specified chain.
+func New(chainID uint16) *Mempool {
+	return &Mempool{
+		chainID: chainID,
+		pool:    make(map[string]transaction.BlockTx),
+	}
+}
+
+// Count returns the current number of transactions in the pool.
+func (mp *Mempool) Count() int {
+	mp.mu.RLock()
+	defer mp.mu.RUnlock()
+
+	return len(mp.pool)
+}
+
+// Upsert adds or replaces a transaction in the mempool. A transaction
+// with the same account and nonce as a pending transaction replaces it.
+// Transactions that aren't signed by the from account or were signed for
+// another chain are rejected.
+func (mp *Mempool) Upsert(tx transaction.BlockTx) error {
+	if err := tx.Validate(mp.chainID); err != nil {
+		return err
+	}
+
+	key, err := mapKey(tx)
+	if err != nil {
+		return err
+	}
+
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	mp.pool[key] = tx
+
+	return nil
+}
+
+// Delete removes a transaction from the mempool.
+func (mp *Mempool) Delete(tx transaction.BlockTx) error {
+	key, err := mapKey(tx)
+	if err != nil {
+		return err
+	}
+
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	delete(mp.pool, key)
+
+	return nil
+}
+
+// Evict removes the transaction the account signed with the specified nonce
+// and reports whether it was in the mempool.
+func (mp *Mempool) Evict(accountID acc.AccountID, nonce uint64) bool {
+	key := fmt.Sprintf("%s:%d", accountID, nonce)
+
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	if _, exists := mp.pool[key]; !exists {
+		return false
+	}
+
+	delete(mp.pool, key)
+
+	return true
+}
+
+// DeleteExpired removes the transactions that can no longer be included in
+// the block with the specified number and returns how many were removed.
+func (mp *Mempool) DeleteExpired(blockNumber uint64) int {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	var removed int
+	for key, tx := range mp.pool {
+		if tx.Expired(blockNumber) {
+			delete(mp.pool, key)
+			removed++
+		}
+	}
+
+	return removed
+}
+
+// Truncate clears all the transactions from the pool.
+func (mp *Mempool) Truncate() {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	mp.pool = make(map[string]transaction.BlockTx)
+}
+
+// Content returns the transactions in the pool grouped by account and
+// ordered by nonce. The function returns the nonce the account's next
+// transaction must use. The transactions from that nonce on without a gap
+// are pending and the rest are queued.
+func (mp *Mempool) Content(nextNonce func(accountID acc.AccountID) uint64) map[acc.AccountID]Content {
+	accounts := make(map[acc.AccountID][]transaction.BlockTx)
+
+	mp.mu.RLock()
+	{
+		for _, tx := range mp.pool {
+			accounts[tx.FromID] = append(accounts[tx.FromID], tx)
+		}
+	}
+	mp.mu.RUnlock()
+
+	content := make(map[acc.AccountID]Content, len(accounts))
+	for accountID, trans := range accounts {
+		sort.Slice(trans, func(i, j int) bool {
+			return trans[i].Nonce < trans[j].Nonce
+		})
+
+		nonce := nextNonce(accountID)
+
+		var c Content
+		for i, tx := range trans {
+			if tx.Nonce != nonce {
+				c.Queued = trans[i:]
+				break
+			}
+			c.Pending = append(c.Pending, tx)
+			nonce++
+		}
+
+		content[accountID] = c
+	}
+
+	return content
+}
+
+// PickBest returns up to the specified number of transactions with the best
+// tip and gas price. The transactions of each account are always returned in
+// nonce order, so a transaction with a high tip can't be picked ahead of the
+// transactions it depends on. A value of 0 or less returns every transaction.
+func (mp *Mempool) PickBest(howMany int) []transaction.BlockTx {
+	accounts := make(map[acc.AccountID][]transaction.BlockTx)
+
+	mp.mu.RLock()
+	{
+		for _, tx := range mp.pool {
+			accounts[tx.FromID] = append(accounts[tx.FromID], tx)
+		}
+	}
+	mp.mu.RUnlock()
+
+	// Order each account's transactions by nonce and start with the first
+	// transaction of every account.
+	var h txHeap
+	for _, trans := range accounts {
+		sort.Slice(trans, func(i, j int) bool {
+			return trans[i].Nonce < trans[j].Nonce
+		})
+		h = append(h, trans)
+	}
+	heap.Init(&h)
+
+	if howMany <= 0 {
+		howMany = mp.Count()
+	}
+
+	// Repeatedly take the best transaction that is next in line for its
+	// account and move that account on to its next transaction.
+	trans := make([]transaction.BlockTx, 0, howMany)
+	for len(trans) < howMany && h.Len() > 0 {
+		next := h[0]
+		trans = append(trans, next[0])
+
+		if len(next) == 1 {
+			heap.Pop(&h)
+			continue
+		}
+
+		h[0] = next[1:]
+		heap.Fix(&h, 0)
+	}
+
+	return trans
+}
+
+// =============================================================================
+
+// mapKey is used to generate the map key.
+func mapKey(tx transaction.BlockTx) (string, error) {
+	if tx.FromID == "" {
+		return "", errors.New("transaction is missing the from account")
+	}
+
+	return fmt.Sprintf("%s:%d", tx.FromID, tx.Nonce), nil
+}
+
+// txHeap orders the pending transactions of each account by the transaction
+// that is next in line for the account. The best transaction has the highest
+// tip, then the highest gas price and then arrived first.
+type txHeap [][]transaction.BlockTx
+
+func (h txHeap) Len() int { return len(h) }
+
+func (h txHeap) Less(i, j int) bool {
+	a, b := h[i][0], h[j][0]
+
+	if c := a.Tip.Cmp(b.Tip); c != 0 {
+		return c > 0
+	}
+
+	if c := a.GasPrice.Cmp(b.GasPrice); c != 0 {
+		return c > 0
+	}
+
+	if a.TimeStamp != b.TimeStamp {
+		return a.TimeStamp < b.TimeStamp
+	}
+
+	return a.FromID < b.FromID
+}
+
+func (h txHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *txHeap) Push(x any) { *h = append(*h, x.([]transaction.BlockTx)) }
+
+func (h *txHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
@@ -0,0 +1,335 @@
+package mempool_test
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"strings"
+	"testing"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+	"github.com/dudakovict/blockchain/foundation/blockchain/chaintest"
+	"github.com/dudakovict/blockchain/foundation/blockchain/mempool"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+)
+
+const chainID = 1
+
+// keys holds a private key for each of the accounts used by the tests.
+var keys = map[string]*ecdsa.PrivateKey{
+	"a": chaintest.Key(1),
+	"b": chaintest.Key(2),
+	"c": chaintest.Key(3),
+	"d": chaintest.Key(4),
+}
+
+// name returns the name of the test account with the specified id.
+func name(id acc.AccountID) string {
+	for name, pk := range keys {
+		if acc.PublicKeyToAccountID(pk.PublicKey) == id {
+			return name
+		}
+	}
+
+	return string(id)
+}
+
+// pending describes a transaction placed in the mempool.
+type pending struct {
+	from      string
+	nonce     uint64
+	tip       uint64
+	gasPrice  uint64
+	timeStamp uint64
+}
+
+// blockTx constructs a signed transaction from the description.
+func blockTx(p pending, chainID uint16) (transaction.BlockTx, error) {
+	pk := keys[p.from]
+	fromID := acc.PublicKeyToAccountID(pk.PublicKey)
+
+	tx, err := transaction.NewTx(chainID, p.nonce, fromID, "0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76", amount.New(1), amount.New(p.tip), nil)
+	if err != nil {
+		return transaction.BlockTx{}, err
+	}
+
+	signedTx, err := tx.Sign(pk)
+	if err != nil {
+		return transaction.BlockTx{}, err
+	}
+
+	blockTx := transaction.NewBlockTx(signedTx, amount.New(p.gasPrice), 1)
+	blockTx.TimeStamp = p.timeStamp
+
+	return blockTx, nil
+}
+
+// order returns the transactions as a space separated list of account
+// name and nonce.
+func order(trans []transaction.BlockTx) string {
+	s := make([]string, len(trans))
+	for i, tx := range trans {
+		s[i] = fmt.Sprintf("%s%d", name(tx.FromID), tx.Nonce)
+	}
+
+	return strings.Join(s, " ")
+}
+
+// =============================================================================
+
+func Test_PickBest(t *testing.T) {
+	type table struct {
+		testCaseID int
+		pending    []pending
+		howMany    int
+		expected   string
+	}
+
+	tt := []table{
+		{
+			testCaseID: 0,
+			pending: []pending{
+				{from: "a", nonce: 1, tip: 1},
+				{from: "a", nonce: 2, tip: 100},
+				{from: "b", nonce: 1, tip: 50},
+				{from: "b", nonce: 2, tip: 5},
+				{from: "c", nonce: 3, tip: 10},
+			},
+			expected: "b1 c3 b2 a1 a2",
+		},
+		{
+			testCaseID: 1,
+			pending: []pending{
+				{from: "a", nonce: 1, tip: 1},
+				{from: "a", nonce: 2, tip: 100},
+				{from: "b", nonce: 1, tip: 50},
+				{from: "b", nonce: 2, tip: 5},
+				{from: "c", nonce: 3, tip: 10},
+			},
+			howMany:  2,
+			expected: "b1 c3",
+		},
+		{
+			testCaseID: 2,
+			pending: []pending{
+				{from: "a", nonce: 3, tip: 1},
+				{from: "a", nonce: 1, tip: 1},
+				{from: "a", nonce: 2, tip: 1},
+			},
+			expected: "a1 a2 a3",
+		},
+		{
+			testCaseID: 3,
+			pending: []pending{
+				{from: "a", nonce: 1, tip: 5, gasPrice: 1},
+				{from: "b", nonce: 1, tip: 5, gasPrice: 3},
+				{from: "c", nonce: 1, tip: 5, gasPrice: 2},
+			},
+			expected: "b1 c1 a1",
+		},
+		{
+			testCaseID: 4,
+			pending: []pending{
+				{from: "a", nonce: 1, tip: 5, timeStamp: 30},
+				{from: "b", nonce: 1, tip: 5, timeStamp: 10},
+				{from: "c", nonce: 1, tip: 5, timeStamp: 20},
+			},
+			expected: "b1 c1 a1",
+		},
+		{
+			testCaseID: 5,
+			pending: []pending{
+				{from: "a", nonce: 1, tip: 1},
+				{from: "a", nonce: 1, tip: 9},
+				{from: "b", nonce: 1, tip: 5},
+			},
+			expected: "a1 b1",
+		},
+		{
+			testCaseID: 6,
+			expected:   "",
+		},
+	}
+
+	for _, tst := range tt {
+		mp := mempool.New(chainID)
+
+		for _, p := range tst.pending {
+			tx, err := blockTx(p, chainID)
+			if err != nil {
+				t.Fatalf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			}
+			if err := mp.Upsert(tx); err != nil {
+				t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			}
+		}
+
+		if got := order(mp.PickBest(tst.howMany)); got != tst.expected {
+			t.Errorf("[case:%d] error: expected order %q got %q", tst.testCaseID, tst.expected, got)
+		}
+	}
+}
+
+func Test_Upsert(t *testing.T) {
+	type table struct {
+		testCaseID int
+		tx         func() (transaction.BlockTx, error)
+		success    bool
+	}
+
+	tt := []table{
+		{
+			testCaseID: 0,
+			tx: func() (transaction.BlockTx, error) {
+				return blockTx(pending{from: "a", nonce: 1}, chainID)
+			},
+			success: true,
+		},
+		{
+			testCaseID: 1,
+			tx: func() (transaction.BlockTx, error) {
+				return blockTx(pending{from: "a", nonce: 1}, chainID+1)
+			},
+			success: false,
+		},
+		{
+			testCaseID: 2,
+			tx: func() (transaction.BlockTx, error) {
+				tx, err := blockTx(pending{from: "a", nonce: 1}, chainID)
+				tx.FromID = acc.PublicKeyToAccountID(keys["b"].PublicKey)
+				return tx, err
+			},
+			success: false,
+		},
+		{
+			testCaseID: 3,
+			tx: func() (transaction.BlockTx, error) {
+				tx, err := blockTx(pending{from: "a", nonce: 1}, chainID)
+				tx.FromID = ""
+				return tx, err
+			},
+			success: false,
+		},
+	}
+
+	for _, tst := range tt {
+		tx, err := tst.tx()
+		if err != nil {
+			t.Fatalf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+
+		mp := mempool.New(chainID)
+		err = mp.Upsert(tx)
+
+		if tst.success && err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+		if !tst.success && err == nil {
+			t.Errorf("[case:%d] error: expected the transaction to be rejected", tst.testCaseID)
+		}
+	}
+}
+
+func Test_DeleteExpired(t *testing.T) {
+	mp := mempool.New(chainID)
+
+	expires := []uint64{0, 5, 10}
+	for i, expiresAt := range expires {
+		pk := keys["a"]
+		tx, err := transaction.NewTx(chainID, uint64(i+1), acc.PublicKeyToAccountID(pk.PublicKey), "0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76", amount.New(1), amount.New(0), nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		tx.ExpiresAt = expiresAt
+
+		signedTx, err := tx.Sign(pk)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := mp.Upsert(transaction.NewBlockTx(signedTx, amount.New(1), 1)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if removed := mp.DeleteExpired(6); removed != 1 {
+		t.Errorf("error: expected 1 expired transaction removed got %d", removed)
+	}
+	if got := order(mp.PickBest(0)); got != "a1 a3" {
+		t.Errorf("error: expected order %q got %q", "a1 a3", got)
+	}
+
+	mp.Truncate()
+	if mp.Count() != 0 {
+		t.Errorf("error: expected an empty mempool got %d transactions", mp.Count())
+	}
+}
+
+func Test_Content(t *testing.T) {
+	mp := mempool.New(chainID)
+
+	// Account a has used nonce 1, so its transactions 2 and 3 are pending and
+	// 5 waits for 4. Account b hasn't sent a transaction yet.
+	txs := []pending{
+		{from: "a", nonce: 2}, {from: "a", nonce: 3}, {from: "a", nonce: 5},
+		{from: "b", nonce: 2}, {from: "b", nonce: 3},
+	}
+	for _, p := range txs {
+		tx, err := blockTx(p, chainID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := mp.Upsert(tx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	nextNonce := func(accountID acc.AccountID) uint64 {
+		if name(accountID) == "a" {
+			return 2
+		}
+		return 1
+	}
+
+	type table struct {
+		testCaseID int
+		account    string
+		pending    string
+		queued     string
+	}
+
+	tt := []table{
+		{testCaseID: 0, account: "a", pending: "a2 a3", queued: "a5"},
+		{testCaseID: 1, account: "b", pending: "", queued: "b2 b3"},
+	}
+
+	content := mp.Content(nextNonce)
+	if len(content) != 2 {
+		t.Fatalf("error: expected 2 accounts got %d", len(content))
+	}
+
+	for _, tst := range tt {
+		c := content[acc.PublicKeyToAccountID(keys[tst.account].PublicKey)]
+
+		if got := order(c.Pending); got != tst.pending {
+			t.Errorf("[case:%d] error: expected pending %q got %q", tst.testCaseID, tst.pending, got)
+		}
+		if got := order(c.Queued); got != tst.queued {
+			t.Errorf("[case:%d] error: expected queued %q got %q", tst.testCaseID, tst.queued, got)
+		}
+	}
+
+	// Evicting the transaction with nonce 2 queues the rest of account a's.
+	fromID := acc.PublicKeyToAccountID(keys["a"].PublicKey)
+	if !mp.Evict(fromID, 2) {
+		t.Fatalf("error: expected the transaction to be evicted")
+	}
+	if mp.Evict(fromID, 2) {
+		t.Errorf("error: expected the transaction to be gone")
+	}
+
+	c := mp.Content(nextNonce)[fromID]
+	if len(c.Pending) != 0 || order(c.Queued) != "a3 a5" {
+		t.Errorf("error: expected a3 a5 queued got pending %q queued %q", order(c.Pending), order(c.Queued))
+	}
+}
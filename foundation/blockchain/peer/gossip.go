@@ -0,0 +1,175 @@
+package peer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+)
+
+// baseURL represents the base URL for the private node endpoints.
+const baseURL = "http://%s/v1/node"
+
+// Gossip shares blocks, transactions and peers with the known peers over
+// the private node endpoints.
+type Gossip struct {
+	host      string
+	peers     *PeerSet
+	client    *http.Client
+	evHandler func(v string, args ...any)
+	syncMu    sync.Mutex
+}
+
+// NewGossip constructs a gossip value for the node running on the specified
+// host. The event handler receives a message for every failed exchange.
+func NewGossip(host string, peers *PeerSet, evHandler func(v string, args ...any)) *Gossip {
+	return &Gossip{
+		host:  host,
+		peers: peers,
+		client: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+		evHandler: evHandler,
+	}
+}
+
+// Host returns the host the node is reachable on by its peers.
+func (g *Gossip) Host() string {
+	return g.host
+}
+
+// Peers returns the set of known peers.
+func (g *Gossip) Peers() *PeerSet {
+	return g.peers
+}
+
+// SendTransaction shares a newly submitted transaction with every known peer.
+func (g *Gossip) SendTransaction(ctx context.Context, tx transaction.BlockTx) {
+	for _, peer := range g.peers.Copy(g.host) {
+		url := fmt.Sprintf("%s/tx/submit", fmt.Sprintf(baseURL, peer.Host))
+		if err := g.send(ctx, http.MethodPost, url, tx, nil); err != nil {
+			g.evHandler("peer: SendTransaction: %s: ERROR: %s", peer.Host, err)
+		}
+	}
+}
+
+// SendBlock shares a newly mined block with every known peer. The first
+// error is returned, which lets a miner know another node has moved ahead.
+func (g *Gossip) SendBlock(ctx context.Context, b block.Block) error {
+	var firstErr error
+
+	for _, peer := range g.peers.Copy(g.host) {
+		url := fmt.Sprintf("%s/block/propose", fmt.Sprintf(baseURL, peer.Host))
+		if err := g.send(ctx, http.MethodPost, url, storage.NewBlockData(b), nil); err != nil {
+			g.evHandler("peer: SendBlock: %s: ERROR: %s", peer.Host, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// RequestStatus asks the peer for its latest block and known peers.
+func (g *Gossip) RequestStatus(ctx context.Context, peer Peer) (PeerStatus, error) {
+	url := fmt.Sprintf("%s/status", fmt.Sprintf(baseURL, peer.Host))
+
+	var status PeerStatus
+	if err := g.send(ctx, http.MethodGet, url, nil, &status); err != nil {
+		return PeerStatus{}, err
+	}
+
+	return status, nil
+}
+
+// ExchangePeers asks every known peer for its status, learns the peers it
+// knows about and registers this node with it. A peer that can't be
+// reached is removed from the set.
+func (g *Gossip) ExchangePeers(ctx context.Context) {
+	self := New(g.host)
+
+	for _, peer := range g.peers.Copy(g.host) {
+		status, err := g.RequestStatus(ctx, peer)
+		if err != nil {
+			g.evHandler("peer: ExchangePeers: %s: ERROR: %s", peer.Host, err)
+			g.peers.Remove(peer)
+			continue
+		}
+
+		for _, known := range status.KnownPeers {
+			if g.peers.Add(known) {
+				g.evHandler("peer: ExchangePeers: %s: added peer %s", peer.Host, known.Host)
+			}
+		}
+
+		if !containsPeer(status.KnownPeers, self) {
+			url := fmt.Sprintf("%s/peers", fmt.Sprintf(baseURL, peer.Host))
+			if err := g.send(ctx, http.MethodPost, url, self, nil); err != nil {
+				g.evHandler("peer: ExchangePeers: %s: ERROR: %s", peer.Host, err)
+			}
+		}
+	}
+}
+
+// =============================================================================
+
+// send performs the HTTP request and decodes the response when a value is
+// provided to decode into.
+func (g *Gossip) send(ctx context.Context, method string, url string, dataSend any, dataRecv any) error {
+	var body io.Reader
+	if dataSend != nil {
+		data, err := json.Marshal(dataSend)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		msg, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	if dataRecv != nil {
+		if err := json.NewDecoder(resp.Body).Decode(dataRecv); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// containsPeer checks if the peer is in the list of peers.
+func containsPeer(peers []Peer, peer Peer) bool {
+	for _, p := range peers {
+		if p.Match(peer.Host) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,229 @@
+package peer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/proof"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage"
+)
+
+// syncBatchSize is the number of blocks requested from a peer at a time.
+const syncBatchSize = 100
+
+// maxBlocksAhead is the furthest ahead of our chain a peer can claim to be.
+// It's more blocks than a chain produces in three years at one block a
+// second, so a peer claiming more is lying about its height.
+const maxBlocksAhead = 100_000_000
+
+// ErrSyncInProgress is returned when a sync is requested while another sync
+// is still running.
+var ErrSyncInProgress = errors.New("sync already in progress")
+
+// Chain represents the behavior required to synchronize the local chain
+// with the chains of the peers.
+type Chain interface {
+	LatestBlock() block.Block
+	ChainWork() *big.Int
+	GetBlock(num uint64) (block.Block, error)
+	Reorganize(ancestor uint64, blocks []block.Block) error
+}
+
+// Sync brings the chain up to date with the peer holding the chain with the
+// most work. The work a peer claims only decides which peers are tried
+// first. The peer's blocks after the last block we have in common are
+// downloaded, then the chain verifies them and checks they hold more work
+// than the blocks they replace before switching to them. When a peer's
+// chain is rejected, the peer with the next most work is tried.
+func (g *Gossip) Sync(ctx context.Context, chain Chain) error {
+	if !g.syncMu.TryLock() {
+		return ErrSyncInProgress
+	}
+	defer g.syncMu.Unlock()
+
+	ourWork := chain.ChainWork()
+
+	type candidate struct {
+		peer   Peer
+		status PeerStatus
+	}
+
+	// Find the peers claiming more work than our chain holds.
+	var candidates []candidate
+	for _, peer := range g.peers.Copy(g.host) {
+		status, err := g.RequestStatus(ctx, peer)
+		if err != nil {
+			g.evHandler("peer: Sync: %s: ERROR: %s", peer.Host, err)
+			continue
+		}
+
+		if status.ChainWork != nil && status.ChainWork.Cmp(ourWork) > 0 {
+			candidates = append(candidates, candidate{peer: peer, status: status})
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].status.ChainWork.Cmp(candidates[j].status.ChainWork) > 0
+	})
+
+	var err error
+	for _, c := range candidates {
+		if err = g.syncPeer(ctx, chain, c.peer, c.status); err == nil {
+			return nil
+		}
+		g.evHandler("peer: Sync: %s: ERROR: %s", c.peer.Host, err)
+	}
+
+	return err
+}
+
+// RequestBlocks asks the peer for the blocks in the specified range.
+func (g *Gossip) RequestBlocks(ctx context.Context, peer Peer, from uint64, to uint64) ([]block.Block, error) {
+	url := fmt.Sprintf("%s/block/list/%d/%d", fmt.Sprintf(baseURL, peer.Host), from, to)
+
+	var blocksData []storage.BlockData
+	if err := g.send(ctx, http.MethodGet, url, nil, &blocksData); err != nil {
+		return nil, err
+	}
+
+	blocks := make([]block.Block, len(blocksData))
+	for i, blockData := range blocksData {
+		b, err := storage.ToBlock(blockData)
+		if err != nil {
+			return nil, err
+		}
+		blocks[i] = b
+	}
+
+	return blocks, nil
+}
+
+// =============================================================================
+
+// syncPeer downloads the peer's blocks after the last block we have in
+// common and hands them to the chain, which only switches to them once
+// they are verified. The blocks of a fork are held until they claim more
+// work than the blocks of our chain they replace, since the chain only
+// switches to a heavier fork. From then on each batch extends the chain
+// and is handed over as it arrives, so a long chain is never held in
+// memory.
+func (g *Gossip) syncPeer(ctx context.Context, chain Chain, peer Peer, status PeerStatus) error {
+	latest := chain.LatestBlock().Header.Number
+
+	if status.LatestBlockNumber > latest+maxBlocksAhead {
+		return fmt.Errorf("peer %s claims block %d, more than %d blocks ahead of our block %d", peer.Host, status.LatestBlockNumber, maxBlocksAhead, latest)
+	}
+
+	g.evHandler("peer: Sync: %s: syncing from block %d, peer is at block %d", peer.Host, latest, status.LatestBlockNumber)
+
+	// The peer's chain can be shorter than ours and still hold more work.
+	searchFrom := latest
+	if status.LatestBlockNumber < searchFrom {
+		searchFrom = status.LatestBlockNumber
+	}
+
+	common, err := g.commonAncestor(ctx, chain, peer, searchFrom)
+	if err != nil {
+		return err
+	}
+
+	ourWork := new(big.Int)
+	for num := common + 1; num <= latest; num++ {
+		b, err := chain.GetBlock(num)
+		if err != nil {
+			return err
+		}
+		ourWork.Add(ourWork, proof.BlockWork(b.Header))
+	}
+
+	if common < latest {
+		g.evHandler("peer: Sync: %s: chain forked after block %d, replacing %d blocks", peer.Host, common, latest-common)
+	}
+
+	ancestor := common
+	var pending []block.Block
+	pendingWork := new(big.Int)
+
+	for from := common + 1; from <= status.LatestBlockNumber; from += syncBatchSize {
+		to := from + syncBatchSize - 1
+		if to > status.LatestBlockNumber {
+			to = status.LatestBlockNumber
+		}
+
+		batch, err := g.RequestBlocks(ctx, peer, from, to)
+		if err != nil {
+			return err
+		}
+
+		if uint64(len(batch)) != to-from+1 {
+			return fmt.Errorf("peer %s returned %d blocks from %d to %d", peer.Host, len(batch), from, to)
+		}
+
+		pending = append(pending, batch...)
+		for _, b := range batch {
+			pendingWork.Add(pendingWork, proof.BlockWork(b.Header))
+		}
+
+		if pendingWork.Cmp(ourWork) <= 0 {
+			continue
+		}
+
+		if err := chain.Reorganize(ancestor, pending); err != nil {
+			return err
+		}
+
+		ancestor = pending[len(pending)-1].Header.Number
+		pending = nil
+		pendingWork = new(big.Int)
+		ourWork = new(big.Int)
+	}
+
+	// The blocks don't claim more work than ours, the chain decides if
+	// they hold more.
+	if len(pending) > 0 {
+		if err := chain.Reorganize(ancestor, pending); err != nil {
+			return err
+		}
+	}
+
+	g.evHandler("peer: Sync: %s: synced to block %d", peer.Host, chain.LatestBlock().Header.Number)
+
+	return nil
+}
+
+// commonAncestor returns the number of the latest block our chain and the
+// peer's chain have in common, searching back from the specified block.
+// Zero means only the genesis is shared.
+func (g *Gossip) commonAncestor(ctx context.Context, chain Chain, peer Peer, start uint64) (uint64, error) {
+	for to := start; to > 0; {
+		from := uint64(1)
+		if to > syncBatchSize {
+			from = to - syncBatchSize + 1
+		}
+
+		blocks, err := g.RequestBlocks(ctx, peer, from, to)
+		if err != nil {
+			return 0, err
+		}
+
+		for i := len(blocks) - 1; i >= 0; i-- {
+			ours, err := chain.GetBlock(blocks[i].Header.Number)
+			if err != nil {
+				return 0, err
+			}
+
+			if ours.Hash() == blocks[i].Hash() {
+				return ours.Header.Number, nil
+			}
+		}
+
+		to = from - 1
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,416 @@
+package peer_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/peer"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+)
+
+// extend builds the specified number of blocks on top of the chain. The
+// fork id is placed in the timestamp so chains built with different ids
+// have different blocks.
+func extend(t *testing.T, chain []block.Block, blocks int, fork uint64) []block.Block {
+	t.Helper()
+
+	chain = append([]block.Block{}, chain...)
+
+	for i := 0; i < blocks; i++ {
+		var parent block.Block
+		if len(chain) > 0 {
+			parent = chain[len(chain)-1]
+		}
+
+		trans := []transaction.BlockTx{
+			{SignedTx: transaction.SignedTx{Tx: transaction.Tx{ChainID: 1, FromID: "0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76", Nonce: parent.Header.Number + 1}}},
+		}
+
+		policy := block.BuildPolicy{
+			BeneficiaryID: acc.AccountID("0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76"),
+			TimeStamp:     fork*1_000_000 + parent.Header.Number + 1,
+		}
+
+		b, err := block.BuildBlock(parent, trans, policy)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		chain = append(chain, b)
+	}
+
+	return chain
+}
+
+// =============================================================================
+
+// chain is a local chain that records the reorganization it's asked for.
+type chain struct {
+	blocks   []block.Block
+	work     *big.Int
+	reject   func(blocks []block.Block) error
+	ancestor uint64
+	received []block.Block
+	reorgs   int
+}
+
+func (c *chain) LatestBlock() block.Block {
+	if len(c.blocks) == 0 {
+		return block.Block{}
+	}
+	return c.blocks[len(c.blocks)-1]
+}
+
+func (c *chain) ChainWork() *big.Int {
+	return c.work
+}
+
+func (c *chain) GetBlock(num uint64) (block.Block, error) {
+	if num == 0 || num > uint64(len(c.blocks)) {
+		return block.Block{}, storage.ErrNotFound
+	}
+	return c.blocks[num-1], nil
+}
+
+func (c *chain) Reorganize(ancestor uint64, blocks []block.Block) error {
+	c.reorgs++
+
+	if c.reject != nil {
+		if err := c.reject(blocks); err != nil {
+			return err
+		}
+	}
+
+	c.ancestor = ancestor
+	c.received = blocks
+	c.blocks = append(c.blocks[:ancestor:ancestor], blocks...)
+
+	return nil
+}
+
+// =============================================================================
+
+// node serves a chain over the private node endpoints Sync uses. The
+// height it claims can be set to more blocks than it holds.
+type node struct {
+	blocks   []block.Block
+	work     int64
+	height   uint64
+	mu       sync.Mutex
+	requests [][2]uint64
+}
+
+func (n *node) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/v1/node/status":
+		height := n.height
+		if height == 0 {
+			height = uint64(len(n.blocks))
+		}
+
+		status := peer.PeerStatus{
+			LatestBlockNumber: height,
+			ChainWork:         big.NewInt(n.work),
+		}
+		json.NewEncoder(w).Encode(status)
+
+	case strings.HasPrefix(r.URL.Path, "/v1/node/block/list/"):
+		var from, to uint64
+		if _, err := fmt.Sscanf(r.URL.Path, "/v1/node/block/list/%d/%d", &from, &to); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		n.mu.Lock()
+		n.requests = append(n.requests, [2]uint64{from, to})
+		n.mu.Unlock()
+
+		// Like the node handler, a range starting after the latest block
+		// is rejected.
+		if from > uint64(len(n.blocks)) {
+			http.Error(w, "invalid block range", http.StatusBadRequest)
+			return
+		}
+
+		blocksData := []storage.BlockData{}
+		for num := from; num <= to && num <= uint64(len(n.blocks)); num++ {
+			blocksData = append(blocksData, storage.NewBlockData(n.blocks[num-1]))
+		}
+		json.NewEncoder(w).Encode(blocksData)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// host starts serving the node and returns the host it can be reached on.
+func (n *node) host(t *testing.T) string {
+	t.Helper()
+
+	srv := httptest.NewServer(n)
+	t.Cleanup(srv.Close)
+
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+// =============================================================================
+
+func Test_Sync(t *testing.T) {
+	shared := extend(t, nil, 20, 0)
+
+	type table struct {
+		testCaseID int
+		ours       []block.Block
+		theirs     []block.Block
+		work       int64
+		ancestor   uint64
+		reorgs     int
+	}
+
+	tt := []table{
+
+		// The peer extends our chain.
+		{testCaseID: 0, ours: shared[:3], theirs: shared[:5], work: 5, ancestor: 3, reorgs: 1},
+
+		// The peer forked after block 2, our blocks 3 and 4 are replaced.
+		{testCaseID: 1, ours: shared[:4], theirs: extend(t, shared[:2], 3, 1), work: 5, ancestor: 2, reorgs: 1},
+
+		// Only the genesis is shared.
+		{testCaseID: 2, ours: shared[:4], theirs: extend(t, nil, 5, 1), work: 5, ancestor: 0, reorgs: 1},
+
+		// We start from the genesis.
+		{testCaseID: 3, ours: nil, theirs: shared[:5], work: 5, ancestor: 0, reorgs: 1},
+
+		// The ancestor is more than a page of blocks back and the fork is
+		// more than a page of blocks long.
+		{testCaseID: 4, ours: extend(t, shared, 130, 2), theirs: extend(t, shared, 140, 3), work: 200, ancestor: 20, reorgs: 1},
+
+		// The peer claims less work than we hold, so it isn't synced from.
+		{testCaseID: 5, ours: shared[:4], theirs: extend(t, shared[:2], 5, 1), work: 3, reorgs: 0},
+
+		// The peer's chain is more than a page of blocks shorter than ours
+		// but holds more work.
+		{testCaseID: 6, ours: extend(t, shared, 130, 2), theirs: extend(t, shared, 10, 3), work: 200, ancestor: 20, reorgs: 1},
+	}
+
+	for _, tst := range tt {
+		local := chain{blocks: append([]block.Block{}, tst.ours...), work: big.NewInt(int64(len(tst.ours)))}
+		remote := node{blocks: tst.theirs, work: tst.work}
+
+		peers := peer.NewPeerSet()
+		peers.Add(peer.New(remote.host(t)))
+
+		g := peer.NewGossip("self", peers, func(string, ...any) {})
+		if err := g.Sync(context.Background(), &local); err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+
+		if local.reorgs != tst.reorgs {
+			t.Errorf("[case:%d] error: expected %d reorganizations got %d", tst.testCaseID, tst.reorgs, local.reorgs)
+			continue
+		}
+
+		// Blocks are requested a page at a time.
+		for _, r := range remote.requests {
+			if r[0] > r[1] || r[1]-r[0] >= 100 {
+				t.Errorf("[case:%d] error: expected a page of at most 100 blocks got %d to %d", tst.testCaseID, r[0], r[1])
+			}
+		}
+
+		if tst.reorgs == 0 {
+			continue
+		}
+
+		if local.ancestor != tst.ancestor {
+			t.Errorf("[case:%d] error: expected ancestor %d got %d", tst.testCaseID, tst.ancestor, local.ancestor)
+		}
+
+		// Every block after the ancestor is handed over in order.
+		exp := tst.theirs[tst.ancestor:]
+		if len(local.received) != len(exp) {
+			t.Errorf("[case:%d] error: expected %d blocks got %d", tst.testCaseID, len(exp), len(local.received))
+			continue
+		}
+		for i := range exp {
+			if local.received[i].Hash() != exp[i].Hash() {
+				t.Errorf("[case:%d] error: expected block %d to be the peer's", tst.testCaseID, exp[i].Header.Number)
+			}
+		}
+
+		if local.LatestBlock().Hash() != tst.theirs[len(tst.theirs)-1].Hash() {
+			t.Errorf("[case:%d] error: expected the peer's latest block after the sync", tst.testCaseID)
+		}
+	}
+}
+
+// Test_SyncBatches checks a long chain is handed over a page of blocks at a
+// time and a peer can't claim more blocks than it holds.
+func Test_SyncBatches(t *testing.T) {
+	theirs := extend(t, nil, 250, 0)
+
+	type table struct {
+		testCaseID int
+		height     uint64
+		expected   string
+		reorgs     int
+		latest     uint64
+	}
+
+	tt := []table{
+		{testCaseID: 0, reorgs: 3, latest: 250},
+		{testCaseID: 1, height: 400, expected: "returned 50 blocks", reorgs: 2, latest: 200},
+		{testCaseID: 2, height: 1 << 40, expected: "ahead", reorgs: 0, latest: 0},
+	}
+
+	for _, tst := range tt {
+		local := chain{work: big.NewInt(0)}
+		remote := node{blocks: theirs, work: 250, height: tst.height}
+
+		peers := peer.NewPeerSet()
+		peers.Add(peer.New(remote.host(t)))
+
+		g := peer.NewGossip("self", peers, func(string, ...any) {})
+		err := g.Sync(context.Background(), &local)
+
+		switch {
+		case tst.expected == "":
+			if err != nil {
+				t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			}
+
+		case err == nil || !strings.Contains(err.Error(), tst.expected):
+			t.Errorf("[case:%d] error: expected an error about %q got %v", tst.testCaseID, tst.expected, err)
+		}
+
+		if local.reorgs != tst.reorgs || local.LatestBlock().Header.Number != tst.latest {
+			t.Errorf("[case:%d] error: expected %d reorganizations to block %d got %d to block %d", tst.testCaseID, tst.reorgs, tst.latest, local.reorgs, local.LatestBlock().Header.Number)
+		}
+
+		if tst.expected == "" && len(local.received) != 50 {
+			t.Errorf("[case:%d] error: expected the last page of 50 blocks got %d", tst.testCaseID, len(local.received))
+		}
+	}
+}
+
+// Test_SyncPeerOrder checks the peers are tried in order of the work they
+// claim and a peer whose chain is rejected doesn't stop the sync.
+func Test_SyncPeerOrder(t *testing.T) {
+	shared := extend(t, nil, 3, 0)
+
+	heaviest := node{blocks: extend(t, shared, 4, 1), work: 30}
+	heavier := node{blocks: extend(t, shared, 3, 2), work: 20}
+	lighter := node{blocks: extend(t, shared, 2, 3), work: 10}
+
+	type table struct {
+		testCaseID int
+		rejected   []*node
+		expected   *node
+		reorgs     int
+	}
+
+	tt := []table{
+		{testCaseID: 0, expected: &heaviest, reorgs: 1},
+		{testCaseID: 1, rejected: []*node{&heaviest}, expected: &heavier, reorgs: 2},
+		{testCaseID: 2, rejected: []*node{&heaviest, &heavier}, expected: &lighter, reorgs: 3},
+		{testCaseID: 3, rejected: []*node{&heaviest, &heavier, &lighter}, reorgs: 3},
+	}
+
+	peers := peer.NewPeerSet()
+	for _, n := range []*node{&lighter, &heaviest, &heavier} {
+		peers.Add(peer.New(n.host(t)))
+	}
+
+	errRejected := errors.New("fork rejected")
+
+	for _, tst := range tt {
+		rejected := make(map[string]bool)
+		for _, n := range tst.rejected {
+			rejected[n.blocks[len(n.blocks)-1].Hash()] = true
+		}
+
+		local := chain{
+			blocks: append([]block.Block{}, shared...),
+			work:   big.NewInt(3),
+			reject: func(blocks []block.Block) error {
+				if rejected[blocks[len(blocks)-1].Hash()] {
+					return errRejected
+				}
+				return nil
+			},
+		}
+
+		g := peer.NewGossip("self", peers, func(string, ...any) {})
+		err := g.Sync(context.Background(), &local)
+
+		if local.reorgs != tst.reorgs {
+			t.Errorf("[case:%d] error: expected %d reorganizations got %d", tst.testCaseID, tst.reorgs, local.reorgs)
+		}
+
+		if tst.expected == nil {
+			if !errors.Is(err, errRejected) {
+				t.Errorf("[case:%d] error: expected %v got %v", tst.testCaseID, errRejected, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+
+		exp := tst.expected.blocks[len(tst.expected.blocks)-1]
+		if local.LatestBlock().Hash() != exp.Hash() {
+			t.Errorf("[case:%d] error: expected the chain of the peer claiming %d work", tst.testCaseID, tst.expected.work)
+		}
+	}
+}
+
+func Test_SyncInProgress(t *testing.T) {
+	shared := extend(t, nil, 3, 0)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	local := chain{
+		blocks: shared[:1],
+		work:   big.NewInt(1),
+		reject: func([]block.Block) error {
+			close(started)
+			<-release
+			return nil
+		},
+	}
+
+	remote := node{blocks: shared, work: 3}
+	peers := peer.NewPeerSet()
+	peers.Add(peer.New(remote.host(t)))
+
+	g := peer.NewGossip("self", peers, func(string, ...any) {})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- g.Sync(context.Background(), &local)
+	}()
+
+	<-started
+	if err := g.Sync(context.Background(), &local); !errors.Is(err, peer.ErrSyncInProgress) {
+		t.Errorf("error: expected %v got %v", peer.ErrSyncInProgress, err)
+	}
+	close(release)
+
+	if err := <-done; err != nil {
+		t.Errorf("error: unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package proof
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+)
+
+// Bounds for the difficulty. Each level is 16 times harder than the last,
+// so the bounds cover every difficulty a sha256 hash can meet.
+const (
+	MinDifficulty = 1
+	MaxDifficulty = 64
+)
+
+// retargetFactor is how far the average block interval can drift from the
+// target before the difficulty changes. A level is 16 times the work, so a
+// change is only made once blocks are 4 times too fast or too slow, which
+// is halfway between two levels.
+const retargetFactor = 4
+
+// Retarget represents the settings for adjusting the difficulty so blocks
+// are mined at the target interval as the hash rate of the network changes.
+type Retarget struct {
+	Difficulty    uint16        // Difficulty of the first block.
+	BlockInterval time.Duration // Target time between blocks, zero keeps the difficulty fixed.
+	Blocks        uint64        // Number of blocks between adjustments.
+}
+
+// IsAdjustment reports whether the difficulty can change at the block with
+// the specified number. Adjustments are made at the start of every window
+// of blocks, once there is a full window of blocks to measure.
+func (r Retarget) IsAdjustment(number uint64) bool {
+	if r.BlockInterval <= 0 || r.Blocks == 0 {
+		return false
+	}
+
+	return number > 2*r.Blocks && (number-1)%r.Blocks == 0
+}
+
+// WindowStart returns the number of the first block of the window measured
+// for the adjustment at the specified block number.
+func (r Retarget) WindowStart(number uint64) uint64 {
+	return number - 1 - r.Blocks
+}
+
+// NextDifficulty calculates the difficulty the block after the parent must
+// have. The first block uses the starting difficulty and outside of an
+// adjustment the parent's difficulty carries over. At an adjustment, the
+// time taken to mine the window of blocks from the first block to the
+// parent is compared to the target interval.
+func (r Retarget) NextDifficulty(parent block.BlockHeader, first block.BlockHeader) uint16 {
+	if parent.Number == 0 {
+		return r.Difficulty
+	}
+
+	if !r.IsAdjustment(parent.Number + 1) {
+		return parent.Difficulty
+	}
+
+	// Block timestamps are recorded in milliseconds.
+	var elapsed time.Duration
+	if parent.TimeStamp > first.TimeStamp {
+		elapsed = time.Duration(parent.TimeStamp-first.TimeStamp) * time.Millisecond
+	}
+	interval := elapsed / time.Duration(parent.Number-first.Number)
+
+	difficulty := parent.Difficulty
+	switch {
+	case interval < r.BlockInterval/retargetFactor && difficulty < MaxDifficulty:
+		difficulty++
+	case interval > r.BlockInterval*retargetFactor && difficulty > MinDifficulty:
+		difficulty--
+	}
+
+	return difficulty
+}
+
+// BlockWork returns the expected number of hashes it took to produce the
+// block, 16 for each level of difficulty. A block signed by an authority
+// out of turn has a difficulty of zero and counts as a single unit of work,
+// one signed in turn counts as 16. Competing chains are compared by the sum
+// of the work of their blocks, not by their length, since a long chain of
+// easy blocks is cheap to produce.
+func BlockWork(header block.BlockHeader) *big.Int {
+	return new(big.Int).Lsh(big.NewInt(1), 4*uint(header.Difficulty))
+}
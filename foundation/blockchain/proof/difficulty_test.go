@@ -0,0 +1,120 @@
+package proof_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/proof"
+)
+
+func Test_Retarget(t *testing.T) {
+	r := proof.Retarget{Difficulty: 3, BlockInterval: 10 * time.Second, Blocks: 5}
+
+	header := func(number uint64, timeStamp uint64, difficulty uint16) block.BlockHeader {
+		return block.BlockHeader{Number: number, TimeStamp: timeStamp, Difficulty: difficulty}
+	}
+
+	type table struct {
+		testCaseID int
+		retarget   proof.Retarget
+		parent     block.BlockHeader
+		first      block.BlockHeader
+		expected   uint16
+	}
+
+	tt := []table{
+		// The first block uses the starting difficulty.
+		{testCaseID: 0, retarget: r, parent: header(0, 0, 0), expected: 3},
+
+		// Outside of an adjustment the parent's difficulty carries over.
+		{testCaseID: 1, retarget: r, parent: header(5, 10_000, 3), expected: 3},
+		{testCaseID: 2, retarget: r, parent: header(11, 15_000, 3), first: header(5, 10_000, 3), expected: 3},
+
+		// 5 blocks in 5s is a 1s interval, more than 4 times too fast.
+		{testCaseID: 3, retarget: r, parent: header(10, 15_000, 3), first: header(5, 10_000, 3), expected: 4},
+
+		// 5 blocks in 250s is a 50s interval, more than 4 times too slow.
+		{testCaseID: 4, retarget: r, parent: header(10, 260_000, 3), first: header(5, 10_000, 3), expected: 2},
+
+		// 5 blocks in 50s is on target.
+		{testCaseID: 5, retarget: r, parent: header(10, 60_000, 3), first: header(5, 10_000, 3), expected: 3},
+
+		// Within the factor of 4 either way nothing changes.
+		{testCaseID: 6, retarget: r, parent: header(10, 22_500, 3), first: header(5, 10_000, 3), expected: 3},
+		{testCaseID: 7, retarget: r, parent: header(10, 210_000, 3), first: header(5, 10_000, 3), expected: 3},
+
+		// The difficulty stays within its bounds.
+		{testCaseID: 8, retarget: r, parent: header(10, 10_000, proof.MaxDifficulty), first: header(5, 10_000, proof.MaxDifficulty), expected: proof.MaxDifficulty},
+		{testCaseID: 9, retarget: r, parent: header(10, 1_000_000, proof.MinDifficulty), first: header(5, 10_000, proof.MinDifficulty), expected: proof.MinDifficulty},
+
+		// A timestamp going backwards is treated as no time passing.
+		{testCaseID: 10, retarget: r, parent: header(10, 5_000, 3), first: header(5, 10_000, 3), expected: 4},
+
+		// Without a block interval the difficulty is fixed.
+		{testCaseID: 11, retarget: proof.Retarget{Difficulty: 3, Blocks: 5}, parent: header(10, 15_000, 3), first: header(5, 10_000, 3), expected: 3},
+	}
+
+	for _, tst := range tt {
+		if got := tst.retarget.NextDifficulty(tst.parent, tst.first); got != tst.expected {
+			t.Errorf("[case:%d] error: expected difficulty %d got %d", tst.testCaseID, tst.expected, got)
+		}
+	}
+}
+
+func Test_IsAdjustment(t *testing.T) {
+	type table struct {
+		testCaseID  int
+		retarget    proof.Retarget
+		number      uint64
+		adjustment  bool
+		windowStart uint64
+	}
+
+	r := proof.Retarget{Difficulty: 3, BlockInterval: 10 * time.Second, Blocks: 5}
+
+	tt := []table{
+		{testCaseID: 0, retarget: r, number: 1},
+		{testCaseID: 1, retarget: r, number: 6},
+		{testCaseID: 2, retarget: r, number: 10},
+		{testCaseID: 3, retarget: r, number: 11, adjustment: true, windowStart: 5},
+		{testCaseID: 4, retarget: r, number: 12},
+		{testCaseID: 5, retarget: r, number: 16, adjustment: true, windowStart: 10},
+		{testCaseID: 6, retarget: proof.Retarget{Blocks: 5}, number: 11},
+		{testCaseID: 7, retarget: proof.Retarget{BlockInterval: time.Second}, number: 11},
+	}
+
+	for _, tst := range tt {
+		if got := tst.retarget.IsAdjustment(tst.number); got != tst.adjustment {
+			t.Errorf("[case:%d] error: expected adjustment %v got %v", tst.testCaseID, tst.adjustment, got)
+			continue
+		}
+		if tst.adjustment {
+			if got := tst.retarget.WindowStart(tst.number); got != tst.windowStart {
+				t.Errorf("[case:%d] error: expected window start %d got %d", tst.testCaseID, tst.windowStart, got)
+			}
+		}
+	}
+}
+
+func Test_BlockWork(t *testing.T) {
+	type table struct {
+		testCaseID int
+		difficulty uint16
+		expected   string
+	}
+
+	tt := []table{
+		{testCaseID: 0, difficulty: 0, expected: "1"},
+		{testCaseID: 1, difficulty: 1, expected: "16"},
+		{testCaseID: 2, difficulty: 6, expected: "16777216"},
+		{testCaseID: 3, difficulty: 16, expected: "18446744073709551616"},
+	}
+
+	for _, tst := range tt {
+		got := proof.BlockWork(block.BlockHeader{Difficulty: tst.difficulty})
+		if got.String() != tst.expected {
+			t.Errorf("[case:%d] error: expected work %s got %s", tst.testCaseID, tst.expected, got)
+		}
+	}
+}
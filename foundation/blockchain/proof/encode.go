@@ -0,0 +1,75 @@
+package proof
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+)
+
+// headerEncoder encodes a block header into a fixed binary layout for the
+// POW inner loop. The nonce is always the last 8 bytes, so each attempt
+// rewrites just those bytes before hashing instead of re-encoding the
+// whole header.
+//
+// Layout, integers are big-endian and strings are prefixed with a 2-byte
+// length:
+//
+//	number(8) prev_block_hash timestamp(8) beneficiary difficulty(2)
+//	mining_reward(32) gas_limit(8) state_root shard_roots_count(2)
+//	shard_roots... trans_root nonce(8)
+type headerEncoder struct {
+	buf []byte
+}
+
+// newHeaderEncoder encodes the header, leaving the nonce to be set by
+// each attempt.
+func newHeaderEncoder(h block.BlockHeader) *headerEncoder {
+	size := 8 + 8 + 2 + 32 + 8 + 2 + 8
+	for _, s := range headerStrings(h) {
+		size += 2 + len(s)
+	}
+
+	buf := make([]byte, 0, size)
+	buf = binary.BigEndian.AppendUint64(buf, h.Number)
+	buf = appendString(buf, h.PrevBlockHash)
+	buf = binary.BigEndian.AppendUint64(buf, h.TimeStamp)
+	buf = appendString(buf, string(h.BeneficiaryID))
+	buf = binary.BigEndian.AppendUint16(buf, h.Difficulty)
+	reward := h.MiningReward.Bytes32()
+	buf = append(buf, reward[:]...)
+	buf = binary.BigEndian.AppendUint64(buf, h.GasLimit)
+	buf = appendString(buf, h.StateRoot)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(h.ShardRoots)))
+	for _, root := range h.ShardRoots {
+		buf = appendString(buf, root)
+	}
+	buf = appendString(buf, h.TransRoot)
+	buf = binary.BigEndian.AppendUint64(buf, h.Nonce)
+
+	return &headerEncoder{buf: buf}
+}
+
+// setNonce overwrites the nonce at the end of the encoded header.
+func (e *headerEncoder) setNonce(nonce uint64) {
+	binary.BigEndian.PutUint64(e.buf[len(e.buf)-8:], nonce)
+}
+
+// hash returns the sha256 digest of the encoded header.
+func (e *headerEncoder) hash() [sha256.Size]byte {
+	return sha256.Sum256(e.buf)
+}
+
+// =============================================================================
+
+// headerStrings returns the variable length fields of the header.
+func headerStrings(h block.BlockHeader) []string {
+	strs := []string{h.PrevBlockHash, string(h.BeneficiaryID), h.StateRoot, h.TransRoot}
+	return append(strs, h.ShardRoots...)
+}
+
+// appendString appends the string prefixed with its length.
+func appendString(buf []byte, s string) []byte {
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(s)))
+	return append(buf, s...)
+}
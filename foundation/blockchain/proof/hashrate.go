@@ -0,0 +1,57 @@
+package proof
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+)
+
+// HashRate represents an estimate of the hashing power of the network.
+type HashRate struct {
+	Blocks        int           `json:"blocks"`
+	Difficulty    uint16        `json:"difficulty"`
+	BlockInterval time.Duration `json:"block_interval"`
+	HashesPerSec  float64       `json:"hashes_per_sec"`
+}
+
+// ExpectedHashes returns the average number of hashes required to solve a
+// block at the specified difficulty. Each level of difficulty requires one
+// more leading hex zero, so every level is 16 times harder than the last.
+func ExpectedHashes(difficulty uint16) float64 {
+	return math.Pow(16, float64(difficulty))
+}
+
+// EstimateHashRate estimates the hash rate of the network from a set of
+// consecutive blocks ordered from oldest to newest. The work required by
+// every block after the first is divided by the time it took to mine them.
+func EstimateHashRate(blocks []block.Block) (HashRate, error) {
+	if len(blocks) < 2 {
+		return HashRate{}, errors.New("at least two blocks are required to estimate the hash rate")
+	}
+
+	first := blocks[0].Header.TimeStamp
+	last := blocks[len(blocks)-1].Header.TimeStamp
+	if last <= first {
+		return HashRate{}, errors.New("block timestamps are not increasing")
+	}
+
+	var work float64
+	for _, b := range blocks[1:] {
+		work += ExpectedHashes(b.Header.Difficulty)
+	}
+
+	// Block timestamps are recorded in milliseconds.
+	elapsed := time.Duration(last-first) * time.Millisecond
+	mined := len(blocks) - 1
+
+	hr := HashRate{
+		Blocks:        mined,
+		Difficulty:    blocks[len(blocks)-1].Header.Difficulty,
+		BlockInterval: elapsed / time.Duration(mined),
+		HashesPerSec:  work / elapsed.Seconds(),
+	}
+
+	return hr, nil
+}
@@ -0,0 +1,213 @@
+package proof
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"time"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/signature"
+)
+
+// Consensus represents the algorithm used to agree on the next block.
+type Consensus string
+
+// Set of consensus algorithms a chain can be configured with.
+const (
+	// ConsensusPOW has miners compete to solve the POW puzzle.
+	ConsensusPOW Consensus = "pow"
+
+	// ConsensusPOA has a fixed set of authorities take turns signing blocks.
+	ConsensusPOA Consensus = "poa"
+)
+
+// ToConsensus converts a string from configuration into a consensus
+// algorithm. An empty string is POW.
+func ToConsensus(consensus string) (Consensus, error) {
+	switch c := Consensus(consensus); c {
+	case "":
+		return ConsensusPOW, nil
+	case ConsensusPOW, ConsensusPOA:
+		return c, nil
+	}
+
+	return "", fmt.Errorf("unknown consensus %q", consensus)
+}
+
+// ErrNotAuthority is returned when a key that doesn't belong to one of the
+// chain's authorities tries to sign a block.
+var ErrNotAuthority = errors.New("signer isn't one of the chain's authorities")
+
+// Set of difficulties a block signed by an authority carries. The block of
+// the authority in turn does the more work, so when an authority out of
+// turn signs a block for the same number, the chain with the block in turn
+// wins.
+const (
+	DifficultyInTurn    uint16 = 1
+	DifficultyOutOfTurn uint16 = 0
+)
+
+// =============================================================================
+
+// InTurn returns the authority scheduled to sign the block with the
+// specified number. The authorities take turns in the order listed.
+func InTurn(authorities []acc.AccountID, number uint64) acc.AccountID {
+	if len(authorities) == 0 {
+		return ""
+	}
+
+	return authorities[number%uint64(len(authorities))]
+}
+
+// Difficulty returns the difficulty of the block with the specified number
+// when it's signed by the signer.
+func Difficulty(authorities []acc.AccountID, number uint64, signerID acc.AccountID) uint16 {
+	if InTurn(authorities, number) == signerID {
+		return DifficultyInTurn
+	}
+
+	return DifficultyOutOfTurn
+}
+
+// SlotDelay returns how long after its parent the signer can sign the block
+// with the specified number. The authority in turn signs once the block
+// interval has passed. Any other authority only signs if the one in turn
+// hasn't, waiting another interval for each place it comes after it, so an
+// authority that is offline delays the chain rather than halting it.
+func SlotDelay(authorities []acc.AccountID, number uint64, signerID acc.AccountID, interval time.Duration) (time.Duration, error) {
+	for i, id := range authorities {
+		if id != signerID {
+			continue
+		}
+
+		n := uint64(len(authorities))
+		places := (uint64(i) + n - number%n) % n
+
+		return interval * time.Duration(places+1), nil
+	}
+
+	return 0, ErrNotAuthority
+}
+
+// WaitForSlot blocks until the delay has passed since the parent block was
+// produced so authorities produce blocks on a steady schedule.
+func WaitForSlot(ctx context.Context, parent block.BlockHeader, delay time.Duration) error {
+	if parent.TimeStamp == 0 || delay <= 0 {
+		return nil
+	}
+
+	slot := time.UnixMilli(int64(parent.TimeStamp)).Add(delay)
+
+	timer := time.NewTimer(time.Until(slot))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ValidateSlot checks the block wasn't produced before the delay passed
+// since its parent, the slot WaitForSlot holds the authorities to.
+func ValidateSlot(header block.BlockHeader, parent block.BlockHeader, delay time.Duration) error {
+	if parent.TimeStamp == 0 || delay <= 0 {
+		return nil
+	}
+
+	slot := parent.TimeStamp + uint64(delay.Milliseconds())
+	if header.TimeStamp < slot {
+		return fmt.Errorf("block produced before its slot, slot %d, block %d", slot, header.TimeStamp)
+	}
+
+	return nil
+}
+
+// POA signs the candidate block constructed by block.BuildBlock with the
+// authority's private key. There is no puzzle to solve, the signature is
+// the proof the block was produced by an authority. The candidate must
+// carry the difficulty for the authority's turn.
+func POA(b block.Block, authorities []acc.AccountID, privateKey *ecdsa.PrivateKey) (block.Block, error) {
+	signerID := acc.PublicKeyToAccountID(privateKey.PublicKey)
+	if !isAuthority(authorities, signerID) {
+		return block.Block{}, ErrNotAuthority
+	}
+
+	if expected := Difficulty(authorities, b.Header.Number, signerID); b.Header.Difficulty != expected {
+		return block.Block{}, fmt.Errorf("block difficulty doesn't match the authority's turn, got %d, exp %d", b.Header.Difficulty, expected)
+	}
+
+	header := b.Header
+	header.Nonce = 0
+	header.Signature = ""
+
+	v, r, s, err := signature.Sign(header, privateKey)
+	if err != nil {
+		return block.Block{}, err
+	}
+
+	b.Header.Nonce = 0
+	b.Header.Signature = signature.SignatureString(v, r, s)
+
+	return b, nil
+}
+
+// ValidatePOA checks the header was signed by one of the authorities with
+// the difficulty for the authority's turn.
+func ValidatePOA(header block.BlockHeader, authorities []acc.AccountID) error {
+	_, err := validatePOA(header, authorities)
+	return err
+}
+
+// validatePOA checks the header like ValidatePOA and returns the authority
+// that signed it.
+func validatePOA(header block.BlockHeader, authorities []acc.AccountID) (acc.AccountID, error) {
+
+	// The signature is 65 bytes, hex encoded with a 0x prefix.
+	if len(header.Signature) != 132 {
+		return "", fmt.Errorf("block signature is missing or malformed, got %q", header.Signature)
+	}
+
+	v, r, s, err := signature.ToVRSFromHexSignature(header.Signature)
+	if err != nil {
+		return "", fmt.Errorf("block signature is malformed: %w", err)
+	}
+
+	if err := signature.VerifySignature(v, r, s); err != nil {
+		return "", fmt.Errorf("block signature is invalid: %w", err)
+	}
+
+	signed := header
+	signed.Signature = ""
+
+	address, err := signature.FromAddress(signed, v, r, s)
+	if err != nil {
+		return "", fmt.Errorf("block signature is invalid: %w", err)
+	}
+
+	signerID := acc.AccountID(address)
+	if !isAuthority(authorities, signerID) {
+		return "", fmt.Errorf("block signed by %s: %w", address, ErrNotAuthority)
+	}
+
+	if expected := Difficulty(authorities, header.Number, signerID); header.Difficulty != expected {
+		return "", fmt.Errorf("block signed by %s has difficulty %d, exp %d", address, header.Difficulty, expected)
+	}
+
+	return signerID, nil
+}
+
+// isAuthority reports whether the account is one of the authorities.
+func isAuthority(authorities []acc.AccountID, accountID acc.AccountID) bool {
+	for _, id := range authorities {
+		if id == accountID {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,211 @@
+package proof_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"testing"
+	"time"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/chaintest"
+	"github.com/dudakovict/blockchain/foundation/blockchain/proof"
+)
+
+// authorityKeys are the keys of the authorities used by the POA tests.
+var authorityKeys = []*ecdsa.PrivateKey{chaintest.Key(1), chaintest.Key(2), chaintest.Key(3)}
+
+// authorities returns the account ids of the authorities in turn order.
+func authorities() []acc.AccountID {
+	ids := make([]acc.AccountID, len(authorityKeys))
+	for i, pk := range authorityKeys {
+		ids[i] = acc.PublicKeyToAccountID(pk.PublicKey)
+	}
+
+	return ids
+}
+
+// =============================================================================
+
+func Test_InTurn(t *testing.T) {
+	ids := authorities()
+
+	type table struct {
+		testCaseID  int
+		authorities []acc.AccountID
+		number      uint64
+		expected    acc.AccountID
+	}
+
+	tt := []table{
+		{testCaseID: 0, authorities: ids, number: 0, expected: ids[0]},
+		{testCaseID: 1, authorities: ids, number: 1, expected: ids[1]},
+		{testCaseID: 2, authorities: ids, number: 2, expected: ids[2]},
+		{testCaseID: 3, authorities: ids, number: 3, expected: ids[0]},
+		{testCaseID: 4, authorities: ids[:1], number: 7, expected: ids[0]},
+		{testCaseID: 5, authorities: nil, number: 1, expected: ""},
+	}
+
+	for _, tst := range tt {
+		if got := proof.InTurn(tst.authorities, tst.number); got != tst.expected {
+			t.Errorf("[case:%d] error: expected %s got %s", tst.testCaseID, tst.expected, got)
+		}
+	}
+}
+
+func Test_POA(t *testing.T) {
+	ids := authorities()
+	b := candidate(t, block.Block{}, block.BuildPolicy{Difficulty: proof.DifficultyInTurn, GasLimit: 100, TimeStamp: 1000})
+
+	// Block 1 is the second authority's turn, the others can only sign it
+	// with the difficulty of a block out of turn.
+	for i, pk := range authorityKeys {
+		_, err := proof.POA(b, ids, pk)
+
+		switch {
+		case i == 1 && err != nil:
+			t.Errorf("[case:%d] error: unexpected error: %v", i, err)
+		case i != 1 && err == nil:
+			t.Errorf("[case:%d] error: expected the difficulty to be rejected", i)
+		}
+	}
+
+	outOfTurn := b
+	outOfTurn.Header.Difficulty = proof.DifficultyOutOfTurn
+	if _, err := proof.POA(outOfTurn, ids, authorityKeys[2]); err != nil {
+		t.Errorf("error: unexpected error signing out of turn: %v", err)
+	}
+
+	if _, err := proof.POA(b, ids, chaintest.Key(4)); !errors.Is(err, proof.ErrNotAuthority) {
+		t.Errorf("error: expected %v got %v", proof.ErrNotAuthority, err)
+	}
+
+	signed, err := proof.POA(b, ids, authorityKeys[1])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	type table struct {
+		testCaseID  int
+		authorities []acc.AccountID
+		change      func(h *block.BlockHeader)
+		success     bool
+	}
+
+	tt := []table{
+		{testCaseID: 0, authorities: ids, change: func(h *block.BlockHeader) {}, success: true},
+		{testCaseID: 1, authorities: []acc.AccountID{ids[1], ids[0], ids[2]}, change: func(h *block.BlockHeader) {}},
+		{testCaseID: 2, authorities: ids, change: func(h *block.BlockHeader) { h.TimeStamp++ }},
+		{testCaseID: 3, authorities: ids, change: func(h *block.BlockHeader) { h.Number += 3 }},
+		{testCaseID: 4, authorities: ids, change: func(h *block.BlockHeader) { h.Nonce = 1 }},
+		{testCaseID: 5, authorities: ids, change: func(h *block.BlockHeader) { h.Signature = "" }},
+		{testCaseID: 6, authorities: ids, change: func(h *block.BlockHeader) { h.Signature = h.Signature[:130] }},
+		{testCaseID: 7, authorities: ids, change: func(h *block.BlockHeader) { h.Signature = h.Signature[:130] + "00" }},
+		{testCaseID: 8, authorities: ids, change: func(h *block.BlockHeader) { h.Signature = "0x" + h.Signature[4:] + "zz" }},
+		{testCaseID: 9, authorities: []acc.AccountID{ids[0], ids[2]}, change: func(h *block.BlockHeader) {}},
+	}
+
+	for _, tst := range tt {
+		header := signed.Header
+		tst.change(&header)
+
+		err := proof.ValidatePOA(header, tst.authorities)
+
+		if tst.success && err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+		if !tst.success && err == nil {
+			t.Errorf("[case:%d] error: expected the header to fail the POA check", tst.testCaseID)
+		}
+	}
+}
+
+func Test_SlotDelay(t *testing.T) {
+	ids := authorities()
+	interval := 2 * time.Second
+
+	type table struct {
+		testCaseID int
+		number     uint64
+		signerID   acc.AccountID
+		expected   time.Duration
+		success    bool
+	}
+
+	tt := []table{
+		{testCaseID: 0, number: 1, signerID: ids[1], expected: interval, success: true},
+		{testCaseID: 1, number: 1, signerID: ids[2], expected: 2 * interval, success: true},
+		{testCaseID: 2, number: 1, signerID: ids[0], expected: 3 * interval, success: true},
+		{testCaseID: 3, number: 3, signerID: ids[2], expected: 3 * interval, success: true},
+		{testCaseID: 4, number: 1, signerID: chaintest.AccountID(4)},
+	}
+
+	for _, tst := range tt {
+		delay, err := proof.SlotDelay(ids, tst.number, tst.signerID, interval)
+
+		if tst.success && (err != nil || delay != tst.expected) {
+			t.Errorf("[case:%d] error: expected %v got %v: %v", tst.testCaseID, tst.expected, delay, err)
+		}
+		if !tst.success && !errors.Is(err, proof.ErrNotAuthority) {
+			t.Errorf("[case:%d] error: expected %v got %v", tst.testCaseID, proof.ErrNotAuthority, err)
+		}
+	}
+}
+
+func Test_ValidateSlot(t *testing.T) {
+	interval := 2 * time.Second
+
+	type table struct {
+		testCaseID int
+		parent     uint64
+		header     uint64
+		interval   time.Duration
+		success    bool
+	}
+
+	tt := []table{
+		{testCaseID: 0, parent: 10_000, header: 12_000, interval: interval, success: true},
+		{testCaseID: 1, parent: 10_000, header: 15_000, interval: interval, success: true},
+		{testCaseID: 2, parent: 10_000, header: 11_999, interval: interval},
+		{testCaseID: 3, parent: 10_000, header: 10_000, interval: interval},
+		{testCaseID: 4, parent: 0, header: 1, interval: interval, success: true},
+		{testCaseID: 5, parent: 10_000, header: 10_000, interval: 0, success: true},
+	}
+
+	for _, tst := range tt {
+		parent := block.BlockHeader{Number: 1, TimeStamp: tst.parent}
+		header := block.BlockHeader{Number: 2, TimeStamp: tst.header}
+
+		err := proof.ValidateSlot(header, parent, tst.interval)
+
+		if tst.success && err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+		if !tst.success && err == nil {
+			t.Errorf("[case:%d] error: expected the header to fail the slot check", tst.testCaseID)
+		}
+	}
+}
+
+func Test_WaitForSlot(t *testing.T) {
+	interval := 50 * time.Millisecond
+	parent := block.BlockHeader{Number: 1, TimeStamp: uint64(time.Now().UnixMilli())}
+
+	if err := proof.WaitForSlot(context.Background(), parent, interval); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now := block.BlockHeader{Number: 2, TimeStamp: uint64(time.Now().UnixMilli())}
+	if err := proof.ValidateSlot(now, parent, interval); err != nil {
+		t.Errorf("error: expected a block produced after the wait to be in its slot: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	parent.TimeStamp = uint64(time.Now().UnixMilli())
+	if err := proof.WaitForSlot(ctx, parent, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("error: expected %v got %v", context.Canceled, err)
+	}
+}
@@ -3,108 +3,177 @@ package proof
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
 	"math"
 	"math/big"
+	"math/bits"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
 	"github.com/dudakovict/blockchain/foundation/blockchain/block"
-	"github.com/dudakovict/blockchain/foundation/blockchain/merkle"
-	"github.com/dudakovict/blockchain/foundation/blockchain/signature"
-	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
 )
 
-// POWArgs represents the set of arguments required to run POW.
-type POWArgs struct {
-	BeneficiaryID acc.AccountID
-	Difficulty    uint16
-	MiningReward  uint64
-	PrevBlock     block.Block
-	StateRoot     string
-	Trans         []transaction.BlockTx
+// Work represents the hashing performed while searching for nonces.
+type Work struct {
+	Attempts uint64
+	Duration time.Duration
 }
 
-// POW constructs a new Block and performs the work to find a nonce that
-// solves the cryptographic POW puzzle.
-func POW(ctx context.Context, args POWArgs) (block.Block, error) {
-
-	// When mining the first block, the previous block's hash will be zero.
-	prevBlockHash := signature.ZeroHash
-	if args.PrevBlock.Header.Number > 0 {
-		prevBlockHash = args.PrevBlock.Hash()
+// Add returns the total of both amounts of work.
+func (w Work) Add(other Work) Work {
+	return Work{
+		Attempts: w.Attempts + other.Attempts,
+		Duration: w.Duration + other.Duration,
 	}
+}
 
-	// Construct a merkle tree from the transaction for this block. The root
-	// of this tree will be part of the block to be mined.
-	tree, err := merkle.NewTree(args.Trans)
-	if err != nil {
-		return block.Block{}, err
+// HashesPerSec returns the rate the hashes were computed at.
+func (w Work) HashesPerSec() float64 {
+	if w.Duration <= 0 {
+		return 0
 	}
 
-	// Construct the block to be mined.
-	b := block.Block{
-		Header: block.BlockHeader{
-			Number:        args.PrevBlock.Header.Number + 1,
-			PrevBlockHash: prevBlockHash,
-			TimeStamp:     uint64(time.Now().UTC().UnixMilli()),
-			BeneficiaryID: args.BeneficiaryID,
-			Difficulty:    args.Difficulty,
-			MiningReward:  args.MiningReward,
-			StateRoot:     args.StateRoot,
-			TransRoot:     tree.RootHex(),
-			Nonce:         0,
-		},
-		MerkleTree: tree,
-	}
+	return float64(w.Attempts) / w.Duration.Seconds()
+}
 
-	// Peform the proof of work mining operation.
-	if err := performPOW(ctx, b); err != nil {
-		return block.Block{}, err
+// =============================================================================
+
+// POW performs the work to find a nonce that solves the cryptographic POW
+// puzzle for the candidate block constructed by block.BuildBlock. The
+// search is split across the specified number of workers. The work
+// performed is returned even when the search is cancelled.
+func POW(ctx context.Context, b block.Block, workers int) (block.Block, Work, error) {
+	start := time.Now()
+
+	nonce, attempts, err := performPOW(ctx, b.Header, workers)
+	work := Work{Attempts: attempts, Duration: time.Since(start)}
+	if err != nil {
+		return block.Block{}, work, err
 	}
+	b.Header.Nonce = nonce
 
-	return b, nil
+	return b, work, nil
 }
 
-// performPOW does the work of mining to find a valid hash for a specified
-// block. Pointer semantics are being used since a nonce is being discovered.
-func performPOW(ctx context.Context, b block.Block) error {
-	// Choose a random starting point for the nonce. After this, the nonce
-	// will be incremented by 1 until a solution is found by us or another node.
+// performPOW does the work of mining to find a nonce that solves the puzzle
+// for the specified block header. Each worker searches its own range of
+// nonces and the first solution found stops the others. The number of
+// hashes computed by all the workers is returned with the nonce.
+func performPOW(ctx context.Context, header block.BlockHeader, workers int) (uint64, uint64, error) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	// Choose a random starting point for the nonce. After this, each
+	// worker's nonce will be incremented by 1 until a solution is found
+	// by us or another node.
 	nBig, err := rand.Int(rand.Reader, big.NewInt(math.MaxInt64))
 	if err != nil {
-		return ctx.Err()
+		return 0, 0, fmt.Errorf("choosing a starting nonce: %w", err)
+	}
+	start := nBig.Uint64()
+
+	// Split the nonces into a range for each worker. The ranges wrap
+	// around so they never overlap.
+	span := math.MaxUint64/uint64(workers) + 1
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	found := make(chan uint64, 1)
+	var attempts atomic.Uint64
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+
+	for i := 0; i < workers; i++ {
+		go func(nonce uint64) {
+			defer wg.Done()
+
+			n, solved := searchNonce(ctx, header, nonce)
+			attempts.Add(n)
+
+			if solved {
+				select {
+				case found <- nonce + n - 1:
+					cancel()
+				default:
+				}
+			}
+		}(start + uint64(i)*span)
 	}
-	b.Header.Nonce = nBig.Uint64()
 
-	// Loop until we or another node finds a solution for the next block.
+	wg.Wait()
+
+	select {
+	case nonce := <-found:
+		return nonce, attempts.Load(), nil
+	default:
+		return 0, attempts.Load(), ctx.Err()
+	}
+}
+
+// searchNonce hashes the header with the nonces from the starting nonce
+// onward until the puzzle is solved or the context is cancelled. The header
+// is encoded once and each attempt only rewrites the nonce. The number of
+// attempts made is returned, the last attempt is the solution when solved.
+func searchNonce(ctx context.Context, header block.BlockHeader, nonce uint64) (uint64, bool) {
+	enc := newHeaderEncoder(header)
+
 	var attempts uint64
 	for {
-		attempts++
 		// Did we timeout trying to solve the problem.
 		if ctx.Err() != nil {
-			return ctx.Err()
+			return attempts, false
 		}
 
-		// Hash the block and check if we have solved the puzzle.
-		hash := b.Hash()
-		if !isHashSolved(b.Header.Difficulty, hash) {
-			b.Header.Nonce++
-			continue
+		// Hash the header and check if we have solved the puzzle.
+		attempts++
+		enc.setNonce(nonce)
+		if isHashSolved(header.Difficulty, enc.hash()) {
+			return attempts, true
 		}
 
-		return nil
+		nonce++
 	}
 }
 
-// isHashSolved checks the hash to make sure it complies with
-// the POW rules. We need to match a difficulty number of 0's.
-func isHashSolved(difficulty uint16, hash string) bool {
-	const match = "0x00000000000000000"
+// ValidatePOW checks the header's nonce solves the POW puzzle at the
+// header's difficulty.
+func ValidatePOW(header block.BlockHeader) error {
+	if !isHashSolved(header.Difficulty, newHeaderEncoder(header).hash()) {
+		return fmt.Errorf("block nonce doesn't solve the POW puzzle, difficulty %d, nonce %d", header.Difficulty, header.Nonce)
+	}
 
-	if len(hash) != 66 {
+	return nil
+}
+
+// isHashSolved checks the hash to make sure it complies with the POW rules.
+// Each level of difficulty is one leading hex zero, so the hash must start
+// with 4 zero bits per level. This is the same as the hash being less than
+// a target of 2^(256-4*difficulty).
+func isHashSolved(difficulty uint16, hash [sha256.Size]byte) bool {
+	zeros := 4 * int(difficulty)
+	if zeros > 8*len(hash) {
 		return false
 	}
 
-	difficulty += 2
-	return hash[:difficulty] == match[:difficulty]
+	for _, b := range hash {
+		if zeros <= 0 {
+			return true
+		}
+
+		if zeros < 8 {
+			return bits.LeadingZeros8(b) >= zeros
+		}
+
+		if b != 0 {
+			return false
+		}
+		zeros -= 8
+	}
+
+	return true
 }
@@ -0,0 +1,206 @@
+package proof_test
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/proof"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+)
+
+const beneficiaryID = acc.AccountID("0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76")
+
+// candidate constructs the candidate block that extends the parent with a
+// single transaction, ready to be sealed.
+func candidate(t *testing.T, parent block.Block, policy block.BuildPolicy) block.Block {
+	t.Helper()
+
+	trans := []transaction.BlockTx{
+		{
+			SignedTx: transaction.SignedTx{Tx: transaction.Tx{ChainID: 1, FromID: beneficiaryID, Nonce: parent.Header.Number + 1}},
+			GasUnits: 1,
+		},
+	}
+
+	if policy.BeneficiaryID == "" {
+		policy.BeneficiaryID = beneficiaryID
+	}
+
+	b, err := block.BuildBlock(parent, trans, policy)
+	if err != nil {
+		t.Fatalf("unexpected error building block: %v", err)
+	}
+
+	return b
+}
+
+// =============================================================================
+
+func Test_POW(t *testing.T) {
+	type table struct {
+		testCaseID int
+		difficulty uint16
+		workers    int
+	}
+
+	tt := []table{
+		{testCaseID: 0, difficulty: 0, workers: 1},
+		{testCaseID: 1, difficulty: 1, workers: 1},
+		{testCaseID: 2, difficulty: 2, workers: 0},
+		{testCaseID: 3, difficulty: 3, workers: 3},
+		{testCaseID: 4, difficulty: 4, workers: 8},
+	}
+
+	for _, tst := range tt {
+		b := candidate(t, block.Block{}, block.BuildPolicy{Difficulty: tst.difficulty, GasLimit: 100})
+
+		mined, work, err := proof.POW(context.Background(), b, tst.workers)
+		if err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+		if work.Attempts == 0 {
+			t.Errorf("[case:%d] error: expected the attempts to be counted", tst.testCaseID)
+		}
+		if err := proof.ValidatePOW(mined.Header); err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+
+		// Only the nonce is set by mining.
+		b.Header.Nonce = mined.Header.Nonce
+		if b.Hash() != mined.Hash() {
+			t.Errorf("[case:%d] error: expected mining to change only the nonce", tst.testCaseID)
+		}
+	}
+}
+
+// Test_POWHeaderEncoding checks every field of the header is covered by the
+// encoding the puzzle is solved over, so a solved header can't be changed
+// without invalidating the proof.
+func Test_POWHeaderEncoding(t *testing.T) {
+	b := candidate(t, block.Block{}, block.BuildPolicy{Difficulty: 5, GasLimit: 100, MiningReward: amount.New(700), TimeStamp: 1000})
+	b.Header.StateRoot = "0x01"
+	b.Header.ShardRoots = []string{"0x02", "0x03"}
+
+	mined, _, err := proof.POW(context.Background(), b, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := proof.ValidatePOW(mined.Header); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	type table struct {
+		testCaseID int
+		change     func(h *block.BlockHeader)
+	}
+
+	tt := []table{
+		{testCaseID: 0, change: func(h *block.BlockHeader) { h.Number++ }},
+		{testCaseID: 1, change: func(h *block.BlockHeader) { h.PrevBlockHash = "0x00" }},
+		{testCaseID: 2, change: func(h *block.BlockHeader) { h.TimeStamp++ }},
+		{testCaseID: 3, change: func(h *block.BlockHeader) { h.BeneficiaryID = "0xF01813E4B85e178A83e29B8E7bF26BD830a25f32" }},
+		{testCaseID: 4, change: func(h *block.BlockHeader) { h.MiningReward = amount.New(701) }},
+		{testCaseID: 5, change: func(h *block.BlockHeader) { h.GasLimit++ }},
+		{testCaseID: 6, change: func(h *block.BlockHeader) { h.StateRoot = "0x04" }},
+		{testCaseID: 7, change: func(h *block.BlockHeader) { h.ShardRoots = []string{"0x03", "0x02"} }},
+		{testCaseID: 8, change: func(h *block.BlockHeader) { h.ShardRoots = append([]string{}, h.ShardRoots[0]) }},
+		{testCaseID: 9, change: func(h *block.BlockHeader) { h.TransRoot = "0x05" }},
+		{testCaseID: 10, change: func(h *block.BlockHeader) { h.Nonce++ }},
+		{testCaseID: 11, change: func(h *block.BlockHeader) { h.Difficulty++ }},
+
+		// Moving bytes between two strings keeps the concatenation the
+		// same, the length prefixes must tell them apart.
+		{testCaseID: 12, change: func(h *block.BlockHeader) { h.ShardRoots = []string{"0x020", "x03"} }},
+	}
+
+	for _, tst := range tt {
+		header := mined.Header
+		header.ShardRoots = append([]string{}, mined.Header.ShardRoots...)
+		tst.change(&header)
+
+		if err := proof.ValidatePOW(header); err == nil {
+			t.Errorf("[case:%d] error: expected the changed header to fail the POW check", tst.testCaseID)
+		}
+	}
+}
+
+func Test_POWCancel(t *testing.T) {
+	b := candidate(t, block.Block{}, block.BuildPolicy{Difficulty: 20, GasLimit: 100})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, work, err := proof.POW(ctx, b, 4)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error: expected %v got %v", context.DeadlineExceeded, err)
+	}
+	if work.Attempts == 0 {
+		t.Errorf("error: expected the attempts made before the cancel to be counted")
+	}
+}
+
+// failingReader is a source of randomness that always fails.
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("no entropy")
+}
+
+// Test_POWRandomFailure checks a failure choosing the starting nonce is
+// reported instead of an empty block.
+func Test_POWRandomFailure(t *testing.T) {
+	reader := rand.Reader
+	rand.Reader = failingReader{}
+	defer func() { rand.Reader = reader }()
+
+	b := candidate(t, block.Block{}, block.BuildPolicy{Difficulty: 1, GasLimit: 100})
+
+	if _, _, err := proof.POW(context.Background(), b, 1); err == nil || !strings.Contains(err.Error(), "no entropy") {
+		t.Errorf("error: expected the random failure to be reported got %v", err)
+	}
+}
+
+func Test_ToConsensus(t *testing.T) {
+	type table struct {
+		testCaseID int
+		value      string
+		expected   proof.Consensus
+		success    bool
+	}
+
+	tt := []table{
+		{testCaseID: 0, value: "", expected: proof.ConsensusPOW, success: true},
+		{testCaseID: 1, value: "pow", expected: proof.ConsensusPOW, success: true},
+		{testCaseID: 2, value: "poa", expected: proof.ConsensusPOA, success: true},
+		{testCaseID: 3, value: "pos"},
+		{testCaseID: 4, value: "POA"},
+	}
+
+	for _, tst := range tt {
+		got, err := proof.ToConsensus(tst.value)
+
+		if !tst.success {
+			if err == nil {
+				t.Errorf("[case:%d] error: expected %q to fail got %s", tst.testCaseID, tst.value, got)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+		if got != tst.expected {
+			t.Errorf("[case:%d] error: expected %s got %s", tst.testCaseID, tst.expected, got)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package proof
+
+import (
+	"errors"
+	"fmt"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+)
+
+// ChainRules represents the settings from genesis the blocks of a chain are
+// validated against.
+type ChainRules struct {
+	Consensus   Consensus
+	Retarget    Retarget
+	Authorities []acc.AccountID
+	GasLimit    uint64 // Gas limit of the first block.
+}
+
+// NextDifficulty calculates the difficulty the block after the parent must
+// be mined at. The header function returns the header of a block before the
+// parent, which is needed when the block starts a new retarget window.
+// Blocks signed by an authority have no puzzle to solve, so their
+// difficulty is that of a block signed in turn.
+func (r ChainRules) NextDifficulty(parent block.BlockHeader, header func(num uint64) (block.BlockHeader, error)) (uint16, error) {
+	if r.Consensus == ConsensusPOA {
+		return DifficultyInTurn, nil
+	}
+
+	number := parent.Number + 1
+	if !r.Retarget.IsAdjustment(number) {
+		return r.Retarget.NextDifficulty(parent, block.BlockHeader{}), nil
+	}
+
+	first, err := header(r.Retarget.WindowStart(number))
+	if err != nil {
+		return 0, fmt.Errorf("reading retarget window: %w", err)
+	}
+
+	return r.Retarget.NextDifficulty(parent, first), nil
+}
+
+// HeaderDifficulty returns the difficulty the header must carry to extend
+// the parent. Under POA a block signed out of turn carries a lower
+// difficulty, which ValidateSeal checks against the block's signer.
+func (r ChainRules) HeaderDifficulty(header block.BlockHeader, parent block.BlockHeader, lookup func(num uint64) (block.BlockHeader, error)) (uint16, error) {
+	if r.Consensus == ConsensusPOA && header.Difficulty == DifficultyOutOfTurn {
+		return DifficultyOutOfTurn, nil
+	}
+
+	return r.NextDifficulty(parent, lookup)
+}
+
+// ParentGasLimit returns the gas limit the block after the parent is bound
+// by. The first block is bound by the genesis gas limit.
+func (r ChainRules) ParentGasLimit(parent block.BlockHeader) uint64 {
+	if parent.Number == 0 {
+		return r.GasLimit
+	}
+
+	return parent.GasLimit
+}
+
+// ValidateSeal checks the header holds a valid proof for the chain's
+// consensus. Under POA the header must also be signed in the slot of the
+// authority that signed it.
+func (r ChainRules) ValidateSeal(header block.BlockHeader, parent block.BlockHeader) error {
+	switch r.Consensus {
+	case ConsensusPOA:
+		signerID, err := validatePOA(header, r.Authorities)
+		if err != nil {
+			return err
+		}
+
+		delay, err := SlotDelay(r.Authorities, header.Number, signerID, r.Retarget.BlockInterval)
+		if err != nil {
+			return err
+		}
+
+		return ValidateSlot(header, parent, delay)
+
+	default:
+		// The POW digest doesn't cover the signature but the block hash
+		// does, so a signature would let anyone change a mined block's
+		// hash without solving the puzzle again.
+		if header.Signature != "" {
+			return errors.New("block signature isn't allowed under pow consensus")
+		}
+
+		return ValidatePOW(header)
+	}
+}
+
+// =============================================================================
+
+// HeaderVerifier checks a chain of headers without any account state, so
+// the chain can be audited from the headers alone. Headers are verified in
+// order and only the headers needed to check the difficulty are kept.
+type HeaderVerifier struct {
+	rules  ChainRules
+	parent block.BlockHeader
+	recent map[uint64]block.BlockHeader
+}
+
+// NewHeaderVerifier constructs a verifier for a chain that starts at the
+// genesis block.
+func NewHeaderVerifier(rules ChainRules) *HeaderVerifier {
+	return &HeaderVerifier{
+		rules:  rules,
+		recent: make(map[uint64]block.BlockHeader),
+	}
+}
+
+// Verify checks the header extends the last header verified. The header
+// must pass the same checks the database makes against a block's header and
+// hold a valid proof for the chain's consensus.
+func (v *HeaderVerifier) Verify(header block.BlockHeader) error {
+	parent := v.parent
+
+	difficulty, err := v.rules.HeaderDifficulty(header, parent, v.header)
+	if err != nil {
+		return fmt.Errorf("block %d: %w", header.Number, err)
+	}
+
+	if err := block.ValidateHeader(header, parent, difficulty, v.rules.ParentGasLimit(parent)); err != nil {
+		return fmt.Errorf("block %d: %w", header.Number, err)
+	}
+
+	if err := v.rules.ValidateSeal(header, parent); err != nil {
+		return fmt.Errorf("block %d: %w", header.Number, err)
+	}
+
+	v.remember(header)
+	v.parent = header
+
+	return nil
+}
+
+// Trust accepts the header as verified without checking it. This lets
+// verification start from a block the caller already holds rather than
+// from genesis. The headers must be trusted in order, starting far enough
+// back to cover the retarget window of the next header.
+func (v *HeaderVerifier) Trust(header block.BlockHeader) {
+	v.remember(header)
+	v.parent = header
+}
+
+// Verified returns the number of the last header verified.
+func (v *HeaderVerifier) Verified() uint64 {
+	return v.parent.Number
+}
+
+// header returns a verified header that can still start a retarget window.
+func (v *HeaderVerifier) header(num uint64) (block.BlockHeader, error) {
+	header, exists := v.recent[num]
+	if !exists {
+		return block.BlockHeader{}, fmt.Errorf("block %d hasn't been verified", num)
+	}
+
+	return header, nil
+}
+
+// remember keeps the header while it can still be the start of a retarget
+// window and drops the header that no longer can be.
+func (v *HeaderVerifier) remember(header block.BlockHeader) {
+	if v.rules.Consensus == ConsensusPOA || v.rules.Retarget.Blocks == 0 {
+		return
+	}
+
+	v.recent[header.Number] = header
+
+	keep := v.rules.Retarget.Blocks + 1
+	if header.Number > keep {
+		delete(v.recent, header.Number-keep)
+	}
+}
@@ -0,0 +1,351 @@
+package proof_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/chaintest"
+	"github.com/dudakovict/blockchain/foundation/blockchain/proof"
+)
+
+// powRules are the rules of a POW chain that adjusts the difficulty every
+// 3 blocks.
+var powRules = proof.ChainRules{
+	Consensus: proof.ConsensusPOW,
+	Retarget:  proof.Retarget{Difficulty: 1, BlockInterval: 10 * time.Second, Blocks: 3},
+	GasLimit:  100,
+}
+
+// powChain mines a chain of the specified number of blocks produced 1ms
+// apart, fast enough for every adjustment to raise the difficulty.
+func powChain(t *testing.T, blocks int) []block.BlockHeader {
+	t.Helper()
+
+	var headers []block.BlockHeader
+	lookup := func(num uint64) (block.BlockHeader, error) {
+		return headers[num-1], nil
+	}
+
+	var parent block.Block
+	for i := 0; i < blocks; i++ {
+		difficulty, err := powRules.NextDifficulty(parent.Header, lookup)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		policy := block.BuildPolicy{
+			Difficulty: difficulty,
+			GasLimit:   powRules.ParentGasLimit(parent.Header),
+			TimeStamp:  uint64(1000 + i),
+		}
+
+		b := chaintest.Mine(t, candidate(t, parent, policy))
+		headers = append(headers, b.Header)
+		parent = b
+	}
+
+	return headers
+}
+
+// breakNonce changes the nonce to one that doesn't solve the puzzle. At a
+// low difficulty many nonces do, so the next few can't be assumed to fail.
+func breakNonce(h *block.BlockHeader) {
+	for h.Nonce++; proof.ValidatePOW(*h) == nil; h.Nonce++ {
+	}
+}
+
+// =============================================================================
+
+func Test_HeaderVerifierPOW(t *testing.T) {
+	headers := powChain(t, 10)
+
+	// The chain must have been through the adjustments at blocks 7 and 10.
+	if headers[6].Difficulty != 2 || headers[9].Difficulty != 3 {
+		t.Fatalf("error: expected difficulty 2 at block 7 and 3 at block 10 got %d and %d", headers[6].Difficulty, headers[9].Difficulty)
+	}
+
+	v := proof.NewHeaderVerifier(powRules)
+	for _, header := range headers {
+		if err := v.Verify(header); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if v.Verified() != 10 {
+		t.Errorf("error: expected block 10 verified got %d", v.Verified())
+	}
+
+	type table struct {
+		testCaseID int
+		index      int
+		change     func(h *block.BlockHeader)
+		reseal     bool
+		expected   string
+	}
+
+	tt := []table{
+		{testCaseID: 0, index: 2, change: breakNonce, expected: "POW puzzle"},
+		{testCaseID: 1, index: 2, change: func(h *block.BlockHeader) { h.Difficulty = 2 }, reseal: true, expected: "difficulty"},
+		{testCaseID: 2, index: 6, change: func(h *block.BlockHeader) { h.Difficulty = 1 }, reseal: true, expected: "difficulty"},
+		{testCaseID: 3, index: 2, change: func(h *block.BlockHeader) { h.Number++ }, reseal: true, expected: "next number"},
+		{testCaseID: 4, index: 2, change: func(h *block.BlockHeader) { h.PrevBlockHash = headers[0].PrevBlockHash }, reseal: true, expected: "parent block hash"},
+		{testCaseID: 5, index: 0, change: func(h *block.BlockHeader) { h.GasLimit = 0 }, reseal: true, expected: "gas limit"},
+		{testCaseID: 6, index: 0, change: func(h *block.BlockHeader) { h.GasLimit = 200 }, reseal: true, expected: "gas limit"},
+		{testCaseID: 7, index: 2, change: func(h *block.BlockHeader) { h.TimeStamp = 1 }, reseal: true, expected: "timestamp"},
+
+		// The POW digest doesn't cover the signature, so the nonce still
+		// solves the puzzle but the block hash has changed.
+		{testCaseID: 8, index: 2, change: func(h *block.BlockHeader) { h.Signature = "0x00" }, expected: "signature"},
+	}
+
+	for _, tst := range tt {
+		header := headers[tst.index]
+		tst.change(&header)
+		if tst.reseal {
+			header = chaintest.Mine(t, block.Block{Header: header}).Header
+		}
+
+		v := proof.NewHeaderVerifier(powRules)
+		for _, h := range headers[:tst.index] {
+			if err := v.Verify(h); err != nil {
+				t.Fatalf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			}
+		}
+
+		err := v.Verify(header)
+		if err == nil {
+			t.Errorf("[case:%d] error: expected the changed header to be rejected", tst.testCaseID)
+			continue
+		}
+		if !strings.Contains(err.Error(), tst.expected) {
+			t.Errorf("[case:%d] error: expected an error about %q got %v", tst.testCaseID, tst.expected, err)
+		}
+	}
+}
+
+func Test_HeaderVerifierTrust(t *testing.T) {
+	headers := powChain(t, 10)
+
+	type table struct {
+		testCaseID int
+		trusted    []block.BlockHeader
+		success    bool
+	}
+
+	tt := []table{
+
+		// Block 7 starts a new window measured from block 3.
+		{testCaseID: 0, trusted: headers[2:6], success: true},
+		{testCaseID: 1, trusted: headers[:6], success: true},
+		{testCaseID: 2, trusted: headers[3:6], success: false},
+		{testCaseID: 3, trusted: headers[5:6], success: false},
+	}
+
+	for _, tst := range tt {
+		v := proof.NewHeaderVerifier(powRules)
+		for _, header := range tst.trusted {
+			v.Trust(header)
+		}
+
+		var err error
+		for _, header := range headers[6:] {
+			if err = v.Verify(header); err != nil {
+				break
+			}
+		}
+
+		if tst.success && err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+		if !tst.success && err == nil {
+			t.Errorf("[case:%d] error: expected the retarget window to be missing", tst.testCaseID)
+		}
+	}
+}
+
+func Test_HeaderVerifierPOA(t *testing.T) {
+	ids := authorities()
+	rules := proof.ChainRules{
+		Consensus:   proof.ConsensusPOA,
+		Retarget:    proof.Retarget{BlockInterval: time.Second},
+		Authorities: ids,
+		GasLimit:    100,
+	}
+
+	// sign seals the block with the key of the authority in turn.
+	sign := func(b block.Block) block.Block {
+		pk := authorityKeys[b.Header.Number%uint64(len(authorityKeys))]
+		signed, err := proof.POA(b, ids, pk)
+		if err != nil {
+			t.Fatalf("unexpected error signing block: %v", err)
+		}
+		return signed
+	}
+
+	var headers []block.BlockHeader
+	var parent block.Block
+	for i := 0; i < 4; i++ {
+		policy := block.BuildPolicy{Difficulty: proof.DifficultyInTurn, GasLimit: 100, TimeStamp: uint64(10_000 + i*1000)}
+		b := sign(candidate(t, parent, policy))
+		headers = append(headers, b.Header)
+		parent = b
+	}
+
+	v := proof.NewHeaderVerifier(rules)
+	for _, header := range headers {
+		if err := v.Verify(header); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	type table struct {
+		testCaseID int
+		index      int
+		change     func(h *block.BlockHeader) block.BlockHeader
+		expected   string
+	}
+
+	tt := []table{
+		{
+			testCaseID: 0,
+			index:      2,
+			change: func(h *block.BlockHeader) block.BlockHeader {
+				h.TimeStamp -= 1
+				return sign(block.Block{Header: *h}).Header
+			},
+			expected: "slot",
+		},
+		{
+			testCaseID: 1,
+			index:      2,
+			change: func(h *block.BlockHeader) block.BlockHeader {
+
+				// Signed by an authority whose turn it isn't at block 3.
+				signed, err := proof.POA(block.Block{Header: *h}, []acc.AccountID{ids[1], ids[0], ids[2]}, authorityKeys[1])
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return signed.Header
+			},
+			expected: "signed by",
+		},
+		{
+			testCaseID: 2,
+			index:      2,
+			change: func(h *block.BlockHeader) block.BlockHeader {
+				h.Difficulty = proof.DifficultyInTurn + 1
+				return *h
+			},
+			expected: "difficulty",
+		},
+		{
+			testCaseID: 3,
+			index:      2,
+			change: func(h *block.BlockHeader) block.BlockHeader {
+				h.Signature = ""
+				return *h
+			},
+			expected: "signature",
+		},
+	}
+
+	for _, tst := range tt {
+		header := headers[tst.index]
+		header = tst.change(&header)
+
+		v := proof.NewHeaderVerifier(rules)
+		for _, h := range headers[:tst.index] {
+			if err := v.Verify(h); err != nil {
+				t.Fatalf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			}
+		}
+
+		err := v.Verify(header)
+		if err == nil {
+			t.Errorf("[case:%d] error: expected the changed header to be rejected", tst.testCaseID)
+			continue
+		}
+		if !strings.Contains(err.Error(), tst.expected) {
+			t.Errorf("[case:%d] error: expected an error about %q got %v", tst.testCaseID, tst.expected, err)
+		}
+	}
+}
+
+func Test_HeaderVerifierPOAOutOfTurn(t *testing.T) {
+	ids := authorities()
+	rules := proof.ChainRules{
+		Consensus:   proof.ConsensusPOA,
+		Retarget:    proof.Retarget{BlockInterval: time.Second},
+		Authorities: ids,
+		GasLimit:    100,
+	}
+
+	// Blocks 1 and 2 are signed in turn a second apart.
+	var headers []block.BlockHeader
+	var parent block.Block
+	for i := 1; i <= 2; i++ {
+		policy := block.BuildPolicy{Difficulty: proof.DifficultyInTurn, GasLimit: 100, TimeStamp: uint64(10_000 + i*1000)}
+		b, err := proof.POA(candidate(t, parent, policy), ids, authorityKeys[i])
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		headers = append(headers, b.Header)
+		parent = b
+	}
+
+	// Block 3 is the first authority's turn, the second can sign it a
+	// second later than it and the third a second later again.
+	type table struct {
+		testCaseID int
+		signer     int
+		timeStamp  uint64
+		success    bool
+	}
+
+	tt := []table{
+		{testCaseID: 0, signer: 0, timeStamp: 13_000, success: true},
+		{testCaseID: 1, signer: 1, timeStamp: 13_000},
+		{testCaseID: 2, signer: 1, timeStamp: 14_000, success: true},
+		{testCaseID: 3, signer: 2, timeStamp: 14_000},
+		{testCaseID: 4, signer: 2, timeStamp: 15_000, success: true},
+	}
+
+	for _, tst := range tt {
+		signerID := ids[tst.signer]
+		policy := block.BuildPolicy{
+			Difficulty: proof.Difficulty(ids, 3, signerID),
+			GasLimit:   100,
+			TimeStamp:  tst.timeStamp,
+		}
+
+		b, err := proof.POA(candidate(t, parent, policy), ids, authorityKeys[tst.signer])
+		if err != nil {
+			t.Fatalf("[case:%d] unexpected error: %v", tst.testCaseID, err)
+		}
+
+		v := proof.NewHeaderVerifier(rules)
+		for _, h := range headers {
+			if err := v.Verify(h); err != nil {
+				t.Fatalf("[case:%d] unexpected error: %v", tst.testCaseID, err)
+			}
+		}
+
+		err = v.Verify(b.Header)
+
+		if tst.success && err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+		if !tst.success && err == nil {
+			t.Errorf("[case:%d] error: expected the block to be signed before its slot", tst.testCaseID)
+		}
+	}
+
+	// A block signed in turn does more work than one signed out of turn.
+	inTurn := proof.BlockWork(block.BlockHeader{Difficulty: proof.DifficultyInTurn})
+	outOfTurn := proof.BlockWork(block.BlockHeader{Difficulty: proof.DifficultyOutOfTurn})
+	if inTurn.Cmp(outOfTurn) <= 0 {
+		t.Errorf("error: expected a block in turn to outweigh one out of turn got %d and %d", inTurn, outOfTurn)
+	}
+}
@@ -0,0 +1,90 @@
+package signature
+
+import (
+	"container/list"
+	"math/big"
+	"sync"
+)
+
+// RecoveryCache is a bounded cache of the addresses recovered from
+// signatures. Recovering the public key is the most expensive part of
+// validating a transaction and the same transaction is validated when it's
+// submitted, when a block is built and when a block is validated. Entries
+// are keyed by the signing hash and the signature, so a value with a
+// different signature is always recovered again.
+type RecoveryCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// recoveryEntry represents an address stored in the cache.
+type recoveryEntry struct {
+	key     string
+	address string
+}
+
+// NewRecoveryCache constructs a cache that holds at most the specified number
+// of addresses. The least recently used address is evicted first.
+func NewRecoveryCache(size int) *RecoveryCache {
+	if size < 1 {
+		size = 1
+	}
+
+	return &RecoveryCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// FromAddress extracts the address for the account that signed the data,
+// only performing the recovery if the result isn't already cached.
+func (c *RecoveryCache) FromAddress(value any, v, r, s *big.Int) (string, error) {
+	data, err := stamp(value)
+	if err != nil {
+		return "", err
+	}
+
+	key := string(data) + string(ToSignatureBytesWithArdanID(v, r, s))
+
+	c.mu.Lock()
+	if elem, exists := c.entries[key]; exists {
+		c.order.MoveToFront(elem)
+		address := elem.Value.(recoveryEntry).address
+		c.mu.Unlock()
+		return address, nil
+	}
+	c.mu.Unlock()
+
+	// The lock isn't held during recovery so other values can be checked
+	// at the same time.
+	address, err := fromAddress(data, v, r, s)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[key]; !exists {
+		c.entries[key] = c.order.PushFront(recoveryEntry{key: key, address: address})
+
+		if c.order.Len() > c.size {
+			oldest := c.order.Back()
+			c.order.Remove(oldest)
+			delete(c.entries, oldest.Value.(recoveryEntry).key)
+		}
+	}
+
+	return address, nil
+}
+
+// Len returns the number of addresses in the cache.
+func (c *RecoveryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
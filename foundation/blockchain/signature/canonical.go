@@ -0,0 +1,49 @@
+package signature
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// CanonicalVersion represents the version of the canonical encoding used for
+// hashing and signing. Any change to the rules below must bump the version
+// since every hash and signature on the chain depends on them. The version
+// is part of the stamp every signature is produced over, so a signature made
+// under one version doesn't verify under another.
+//
+// Version 1 rules:
+//   - The value is encoded as JSON using its json struct tags.
+//   - Object keys are sorted in ascending byte order at every level.
+//   - Numbers are written exactly as encoded, integers never use exponents.
+//   - HTML characters are not escaped and there is no insignificant whitespace.
+const CanonicalVersion = 1
+
+// Canonical returns the canonical JSON encoding of the value. This encoding
+// doesn't depend on the order fields are declared in a Go struct, so other
+// implementations can reproduce the bytes that get hashed.
+func Canonical(value any) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode into generic values so objects become maps, which are encoded
+	// with sorted keys. Numbers are kept as their original text.
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var generic any
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(generic); err != nil {
+		return nil, err
+	}
+
+	// The encoder always adds a trailing newline.
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
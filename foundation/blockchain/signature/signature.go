@@ -4,7 +4,6 @@ import (
 	"crypto/ecdsa"
 	"crypto/sha256"
 	"encoding/hex"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
@@ -23,9 +22,10 @@ const embID = 29
 
 // =============================================================================
 
-// Hash returns a unique string for the value.
+// Hash returns a unique string for the value. The hash is calculated over
+// the canonical encoding of the value.
 func Hash(value any) string {
-	data, err := json.Marshal(value)
+	data, err := Canonical(value)
 	if err != nil {
 		return ZeroHash
 	}
@@ -95,6 +95,13 @@ func FromAddress(value any, v, r, s *big.Int) (string, error) {
 		return "", err
 	}
 
+	return fromAddress(data, v, r, s)
+}
+
+// fromAddress extracts the address for the account that signed the
+// specified signing hash.
+func fromAddress(data []byte, v, r, s *big.Int) (string, error) {
+
 	// Convert the [R|S|V] format into the original 65 bytes.
 	sig := ToSignatureBytes(v, r, s)
 
@@ -108,6 +115,13 @@ func FromAddress(value any, v, r, s *big.Int) (string, error) {
 	return crypto.PubkeyToAddress(*publicKey).String(), nil
 }
 
+// SigningHash returns the 32 byte hash that is signed for the value. This is
+// the data a client implementation must reproduce to produce signatures the
+// blockchain accepts.
+func SigningHash(value any) ([]byte, error) {
+	return stamp(value)
+}
+
 // SignatureString returns the signature as a string.
 func SignatureString(v, r, s *big.Int) string {
 	return hexutil.Encode(ToSignatureBytesWithArdanID(v, r, s))
@@ -158,18 +172,20 @@ func ToSignatureBytesWithArdanID(v, r, s *big.Int) []byte {
 // =============================================================================
 
 // stamp returns a hash of 32 bytes that represents this data with
-// the Ardan stamp embedded into the final hash.
+// the Ardan stamp embedded into the final hash. The stamp names the
+// canonical encoding version, so a signature only verifies under the
+// encoding rules it was produced with.
 func stamp(value any) ([]byte, error) {
 
-	// Marshal the data.
-	v, err := json.Marshal(value)
+	// Marshal the data into its canonical form.
+	v, err := Canonical(value)
 	if err != nil {
 		return nil, err
 	}
 
 	// This stamp is used so signatures we produce when signing data
 	// are always unique to the Ardan blockchain.
-	stamp := []byte(fmt.Sprintf("\x19Emb Signed Message:\n%d", len(v)))
+	stamp := []byte(fmt.Sprintf("\x19Emb Signed Message v%d:\n%d", CanonicalVersion, len(v)))
 
 	// Hash the stamp and txHash together in a final 32 byte array
 	// that represents the data.
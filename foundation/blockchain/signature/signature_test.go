@@ -0,0 +1,209 @@
+package signature_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+	"github.com/dudakovict/blockchain/foundation/blockchain/signature"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// vector represents a signing test vector as produced by the vectors tool
+// in app/tooling/vectors. These values must only change along with
+// signature.CanonicalVersion.
+type vector struct {
+	testCaseID  int
+	privateKey  string
+	tx          transaction.Tx
+	txJSON      string
+	signingHash string
+	signature   string
+	recovered   string
+}
+
+var vectors = []vector{
+	{
+		testCaseID:  0,
+		privateKey:  "04062e46550fa8ab227666523c3da550dfb4abd3cd982d878465e38d59aca3bc",
+		tx:          transaction.Tx{ChainID: 1, Nonce: 1, FromID: "0xf6d20052F4ccDca9e5E31502791cc9f700909157", ToID: "0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76", Value: amount.New(100), Tip: amount.New(10)},
+		txJSON:      `{"chain_id":1,"data":null,"from":"0xf6d20052F4ccDca9e5E31502791cc9f700909157","nonce":1,"tip":"0xa","to":"0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76","value":"0x64"}`,
+		signingHash: "0xc691ef6e423bae22d96f4e83aedd1228d0da54525ab60103aab0b49a4c99dd75",
+		signature:   "0x6c88ffec8a4489cda35e081a1543ef0b8ce89a897319c19b2facd74d1fee57a1671d6b4e96ae3f206b4ac27837e1f63d40f4c51471a1a00058b20a20d8fca3581e",
+		recovered:   "0xf6d20052F4ccDca9e5E31502791cc9f700909157",
+	},
+	{
+		testCaseID:  1,
+		privateKey:  "340e1d10a75153ead2c201fed9aebee1333752a82152316dac66de34a6684c2b",
+		tx:          transaction.Tx{ChainID: 1, Nonce: 7, FromID: "0x1ffe0b02337D16c483203e2902E8C99b8b0ee955", ToID: "0x6Fe6CF3c8fF57c58d24BfC869668F48BCbDb3BD9", Value: amount.New(1), Data: []byte("hello")},
+		txJSON:      `{"chain_id":1,"data":"aGVsbG8=","from":"0x1ffe0b02337D16c483203e2902E8C99b8b0ee955","nonce":7,"tip":"0x0","to":"0x6Fe6CF3c8fF57c58d24BfC869668F48BCbDb3BD9","value":"0x1"}`,
+		signingHash: "0x947b9f9ae9b2a60f8038eb47a480ad2e9d2501fc84317490549cb357555fc627",
+		signature:   "0x71c166d2d8f7ef7b82164b179fee1734d27ca295ae6c98c90471bbbe2c7da221334a6b5ddf271a533171d15953b3f2fcf38fcbca0e43042a53729fdfe8268e381e",
+		recovered:   "0x1ffe0b02337D16c483203e2902E8C99b8b0ee955",
+	},
+	{
+		testCaseID:  2,
+		privateKey:  "f145351680a466c7262db76fac9b7489dd6dd9a3325048623743bd67b099ea9e",
+		tx:          transaction.Tx{ChainID: math.MaxUint16, Nonce: math.MaxUint64, FromID: "0x81A62D7B73BE0C99dF524D5A8937f0DEA1C1C825", ToID: "0xa988b1866EaBF72B4c53b592c97aAD8e4b9bDCC0", Value: maxAmount(), Tip: maxAmount()},
+		txJSON:      `{"chain_id":65535,"data":null,"from":"0x81A62D7B73BE0C99dF524D5A8937f0DEA1C1C825","nonce":18446744073709551615,"tip":"0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff","to":"0xa988b1866EaBF72B4c53b592c97aAD8e4b9bDCC0","value":"0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"}`,
+		signingHash: "0x016d466c9de8d165a68bea6a5cc97c512c58c5cf1fc2739b77b0209b35aa95c4",
+		signature:   "0x4b962904031239b09df947d840bfbcad854aa192fef9667d7fd3d1ac8498fd6374843b0e2a795d24c5ea97a495687eeb0126038a211e0d0e430e6279953177801d",
+		recovered:   "0x81A62D7B73BE0C99dF524D5A8937f0DEA1C1C825",
+	},
+	{
+		testCaseID:  3,
+		privateKey:  "2a2f52a7876e375692c0b41876af4188af8f7549564566252656ce764133c000",
+		tx:          transaction.Tx{ChainID: 1, Type: transaction.TypeRotateKey, Nonce: 2, FromID: "0x9CdF4B180f23Eb12FFEaf7D291d910314C259EC6", ToID: "0xF01813E4B85e178A83e29B8E7bF26BD830a25f32"},
+		txJSON:      `{"chain_id":1,"data":null,"from":"0x9CdF4B180f23Eb12FFEaf7D291d910314C259EC6","nonce":2,"tip":"0x0","to":"0xF01813E4B85e178A83e29B8E7bF26BD830a25f32","type":"rotate_key","value":"0x0"}`,
+		signingHash: "0x21c202abac50d5da52682c40dfbcae3578765778e497c28020ff6b7993f3bea2",
+		signature:   "0x6d7ab536edf4e0d8fc9752c3a117e95faf9d0767e601fd0f4eae5ee5ad57b68b4d45e7cb478645c1616e780d4ff9482db82431bb3c3f3a8633113c6ee89af9f11d",
+		recovered:   "0x9CdF4B180f23Eb12FFEaf7D291d910314C259EC6",
+	},
+}
+
+func maxAmount() amount.Amount {
+	a, err := amount.Parse("0x" + strings.Repeat("f", 64))
+	if err != nil {
+		panic(err)
+	}
+
+	return a
+}
+
+// =============================================================================
+
+func Test_Canonical(t *testing.T) {
+	for _, tst := range vectors {
+		data, err := signature.Canonical(tst.tx)
+		if err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+		if string(data) != tst.txJSON {
+			t.Errorf("[case:%d] error: expected encoding %s got %s", tst.testCaseID, tst.txJSON, data)
+		}
+	}
+}
+
+func Test_CanonicalRules(t *testing.T) {
+	type nested struct {
+		Zulu  string `json:"zulu"`
+		Alpha int    `json:"alpha"`
+	}
+
+	type table struct {
+		testCaseID int
+		value      any
+		expected   string
+	}
+
+	tt := []table{
+		{testCaseID: 0, value: nested{Zulu: "z", Alpha: 1}, expected: `{"alpha":1,"zulu":"z"}`},
+		{testCaseID: 1, value: map[string]any{"b": nested{Zulu: "<&>"}, "a": []int{3, 1}}, expected: `{"a":[3,1],"b":{"alpha":0,"zulu":"<&>"}}`},
+		{testCaseID: 2, value: uint64(math.MaxUint64), expected: `18446744073709551615`},
+		{testCaseID: 3, value: map[string]int{"B": 1, "a": 2, "_": 3}, expected: `{"B":1,"_":3,"a":2}`},
+	}
+
+	for _, tst := range tt {
+		data, err := signature.Canonical(tst.value)
+		if err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+		if string(data) != tst.expected {
+			t.Errorf("[case:%d] error: expected encoding %s got %s", tst.testCaseID, tst.expected, data)
+		}
+	}
+}
+
+func Test_SigningHash(t *testing.T) {
+	for _, tst := range vectors {
+		hash, err := signature.SigningHash(tst.tx)
+		if err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+		if got := hexutil.Encode(hash); got != tst.signingHash {
+			t.Errorf("[case:%d] error: expected signing hash %s got %s", tst.testCaseID, tst.signingHash, got)
+		}
+	}
+}
+
+func Test_Sign(t *testing.T) {
+	for _, tst := range vectors {
+		privateKey, err := crypto.HexToECDSA(tst.privateKey)
+		if err != nil {
+			t.Fatalf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+
+		if id := acc.PublicKeyToAccountID(privateKey.PublicKey); id != tst.tx.FromID {
+			t.Fatalf("[case:%d] error: expected the key for %s got %s", tst.testCaseID, tst.tx.FromID, id)
+		}
+
+		v, r, s, err := signature.Sign(tst.tx, privateKey)
+		if err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+		if got := signature.SignatureString(v, r, s); got != tst.signature {
+			t.Errorf("[case:%d] error: expected signature %s got %s", tst.testCaseID, tst.signature, got)
+		}
+
+		if err := signature.VerifySignature(v, r, s); err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+
+		v, r, s, err = signature.ToVRSFromHexSignature(tst.signature)
+		if err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+
+		address, err := signature.FromAddress(tst.tx, v, r, s)
+		if err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+		if address != tst.recovered {
+			t.Errorf("[case:%d] error: expected address %s got %s", tst.testCaseID, tst.recovered, address)
+		}
+
+		// A signature over one transaction must not recover the signer of
+		// another.
+		changed := tst.tx
+		changed.Nonce++
+		if address, err := signature.FromAddress(changed, v, r, s); err == nil && address == tst.recovered {
+			t.Errorf("[case:%d] error: expected a changed transaction to recover another address", tst.testCaseID)
+		}
+	}
+}
+
+func Test_RecoveryCache(t *testing.T) {
+	cache := signature.NewRecoveryCache(2)
+
+	for _, tst := range vectors {
+		v, r, s, err := signature.ToVRSFromHexSignature(tst.signature)
+		if err != nil {
+			t.Fatalf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+
+		for i := 0; i < 2; i++ {
+			address, err := cache.FromAddress(tst.tx, v, r, s)
+			if err != nil {
+				t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+				continue
+			}
+			if address != tst.recovered {
+				t.Errorf("[case:%d] error: expected address %s got %s", tst.testCaseID, tst.recovered, address)
+			}
+		}
+	}
+
+	if cache.Len() > 2 {
+		t.Errorf("error: expected the cache to hold at most 2 entries got %d", cache.Len())
+	}
+}
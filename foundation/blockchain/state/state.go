@@ -0,0 +1,504 @@
+// Package state is the core API for the blockchain. It ties the database,
+// mempool, peers and proof of work together and implements the rules for
+// accepting transactions and blocks.
+package state
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/database"
+	"github.com/dudakovict/blockchain/foundation/blockchain/genesis"
+	"github.com/dudakovict/blockchain/foundation/blockchain/mempool"
+	"github.com/dudakovict/blockchain/foundation/blockchain/peer"
+	"github.com/dudakovict/blockchain/foundation/blockchain/proof"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+)
+
+// ErrNoTransactions is returned when a block is mined while the mempool is
+// empty.
+var ErrNoTransactions = errors.New("no transactions in mempool")
+
+// ErrMiningDisabled is returned when a block is mined while mining is
+// paused or the node has no beneficiary.
+var ErrMiningDisabled = errors.New("mining is paused or has no beneficiary")
+
+// hashRateBlocks is the number of recent blocks used to estimate the hash
+// rate of the network.
+const hashRateBlocks = 10
+
+// EventHandler defines a function that is called when events occur in the
+// processing of blocks and transactions.
+type EventHandler func(v string, args ...any)
+
+// PanicHandler defines a function that is called when a goroutine running
+// node operations recovers from a panic.
+type PanicHandler func(op string, rec any, trace []byte)
+
+// Worker represents the behavior required to be implemented by any package
+// providing support for mining, peer updates and syncing.
+type Worker interface {
+	Shutdown()
+	SignalStartMining()
+	SignalCancelMining()
+	SignalSync()
+}
+
+// =============================================================================
+
+// Config represents the configuration required to start the blockchain node.
+type Config struct {
+	BeneficiaryID acc.AccountID
+	SignerKey     *ecdsa.PrivateKey // Key of the authority signing blocks under POA.
+	MiningWorkers int               // Number of goroutines searching for a nonce under POW.
+	GasTarget     uint64            // Gas limit the miner moves the chain towards, 0 keeps the current limit.
+	Host          string
+	Storage       storage.Storage
+	Genesis       genesis.Genesis
+	KnownPeers    *peer.PeerSet
+	EvHandler     EventHandler
+	PanicHandler  PanicHandler
+}
+
+// State manages the blockchain database.
+type State struct {
+	signerKey     *ecdsa.PrivateKey
+	miningWorkers int
+	gasTarget     uint64
+	evHandler     EventHandler
+	panicHandler  PanicHandler
+
+	workMu sync.Mutex
+	work   proof.Work
+
+	miningMu      sync.RWMutex
+	beneficiaryID acc.AccountID
+	miningPaused  bool
+
+	genesis genesis.Genesis
+	db      *database.Database
+	mempool *mempool.Mempool
+	gossip  *peer.Gossip
+
+	// Worker is set by the package running the mining, peer and sync
+	// operations before the node accepts requests.
+	Worker Worker
+}
+
+// New constructs a new blockchain for data management. The blocks already
+// held by the storage are replayed to rebuild the state.
+func New(cfg Config) (*State, error) {
+
+	// Build a safe event handler function for use.
+	ev := func(v string, args ...any) {
+		if cfg.EvHandler != nil {
+			cfg.EvHandler(v, args...)
+		}
+	}
+
+	db, err := database.New(cfg.Genesis, cfg.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	if db.Consensus() == proof.ConsensusPOA && cfg.BeneficiaryID != "" && cfg.SignerKey == nil {
+		return nil, errors.New("a signer key is required to produce blocks under poa consensus")
+	}
+
+	mp := mempool.New(cfg.Genesis.ChainID)
+
+	// Remove the transactions from the mempool as blocks are applied, no
+	// matter if the block was mined, proposed by a peer or synced.
+	db.AddPostTxHook(database.PostTxHookFunc(func(b block.Block, tx transaction.BlockTx, txErr error) {
+		mp.Delete(tx)
+	}))
+
+	state := State{
+		beneficiaryID: cfg.BeneficiaryID,
+		signerKey:     cfg.SignerKey,
+		miningWorkers: cfg.MiningWorkers,
+		gasTarget:     cfg.GasTarget,
+		evHandler:     ev,
+		panicHandler:  cfg.PanicHandler,
+		genesis:       cfg.Genesis,
+		db:            db,
+		mempool:       mp,
+		gossip:        peer.NewGossip(cfg.Host, cfg.KnownPeers, ev),
+	}
+
+	return &state, nil
+}
+
+// Shutdown cleanly brings the node down.
+func (s *State) Shutdown() error {
+	s.evHandler("state: shutdown: started")
+	defer s.evHandler("state: shutdown: completed")
+
+	// Make sure the worker is stopped before the storage is closed.
+	if s.Worker != nil {
+		s.Worker.Shutdown()
+	}
+
+	return s.db.Close()
+}
+
+// =============================================================================
+
+// Recover is deferred by the goroutines running node operations. A panic is
+// reported with its stack trace and the goroutine carries on from where the
+// recovering function returns, so one bad block or message can't take the
+// node down.
+func (s *State) Recover(op string) {
+	rec := recover()
+	if rec == nil {
+		return
+	}
+
+	trace := debug.Stack()
+
+	if s.panicHandler != nil {
+		s.panicHandler(op, rec, trace)
+		return
+	}
+
+	s.evHandler("%s: PANIC [%v] TRACE[%s]", op, rec, trace)
+}
+
+// MiningWork returns the total work this node has performed mining blocks.
+func (s *State) MiningWork() proof.Work {
+	s.workMu.Lock()
+	defer s.workMu.Unlock()
+
+	return s.work
+}
+
+// HashRate estimates the hash rate of the network from the most recent
+// blocks.
+func (s *State) HashRate() (proof.HashRate, error) {
+	latest := s.db.LatestBlock().Header.Number
+
+	first := uint64(1)
+	if latest > hashRateBlocks {
+		first = latest - hashRateBlocks + 1
+	}
+
+	var blocks []block.Block
+	for num := first; num <= latest; num++ {
+		b, err := s.db.GetBlock(num)
+		if err != nil {
+			return proof.HashRate{}, err
+		}
+		blocks = append(blocks, b)
+	}
+
+	return proof.EstimateHashRate(blocks)
+}
+
+// Genesis returns a copy of the genesis information.
+func (s *State) Genesis() genesis.Genesis {
+	return s.genesis
+}
+
+// BeneficiaryID returns the account receiving the rewards for the blocks
+// this node mines. Mining is disabled when it's empty.
+func (s *State) BeneficiaryID() acc.AccountID {
+	s.miningMu.RLock()
+	defer s.miningMu.RUnlock()
+
+	return s.beneficiaryID
+}
+
+// SetBeneficiaryID changes the account receiving the rewards for the blocks
+// mined from now on. A block already being mined keeps the old beneficiary.
+// An empty account disables mining.
+func (s *State) SetBeneficiaryID(beneficiaryID acc.AccountID) error {
+	if s.db.Consensus() == proof.ConsensusPOA && beneficiaryID != "" && s.signerKey == nil {
+		return errors.New("a signer key is required to produce blocks under poa consensus")
+	}
+
+	s.miningMu.Lock()
+	s.beneficiaryID = beneficiaryID
+	s.miningMu.Unlock()
+
+	s.evHandler("state: SetBeneficiaryID: beneficiary[%s]", beneficiaryID)
+	s.signalStartMining()
+
+	return nil
+}
+
+// PauseMining stops the block being mined and keeps the node from mining
+// until ResumeMining is called. Blocks from peers are still applied.
+func (s *State) PauseMining() {
+	s.miningMu.Lock()
+	s.miningPaused = true
+	s.miningMu.Unlock()
+
+	s.evHandler("state: PauseMining: mining paused")
+	s.signalCancelMining()
+}
+
+// ResumeMining lets the node mine again after PauseMining.
+func (s *State) ResumeMining() {
+	s.miningMu.Lock()
+	s.miningPaused = false
+	s.miningMu.Unlock()
+
+	s.evHandler("state: ResumeMining: mining resumed")
+	s.signalStartMining()
+}
+
+// MiningPaused reports whether mining was paused by PauseMining.
+func (s *State) MiningPaused() bool {
+	s.miningMu.RLock()
+	defer s.miningMu.RUnlock()
+
+	return s.miningPaused
+}
+
+// DB returns the database holding the accounts and blocks.
+func (s *State) DB() *database.Database {
+	return s.db
+}
+
+// Mempool returns the transactions waiting to be mined.
+func (s *State) Mempool() *mempool.Mempool {
+	return s.mempool
+}
+
+// Gossip returns the value used to talk to the peers.
+func (s *State) Gossip() *peer.Gossip {
+	return s.gossip
+}
+
+// =============================================================================
+
+// UpsertWalletTransaction accepts a transaction signed by a wallet, adds it
+// to the mempool and shares it with the peers.
+func (s *State) UpsertWalletTransaction(signedTx transaction.SignedTx) error {
+
+	// The gas units are set by the chain, not the submitter.
+	gasUnits, err := s.db.GasUnits(signedTx.Tx)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkExpiry(signedTx.Tx); err != nil {
+		return err
+	}
+
+	tx := transaction.NewBlockTx(signedTx, s.genesis.GasPrice, gasUnits)
+	if err := s.mempool.Upsert(tx); err != nil {
+		return err
+	}
+
+	// Share the transaction with the peers without holding up the caller.
+	go func() {
+		defer s.Recover("state: SendTransaction")
+		s.gossip.SendTransaction(context.Background(), tx)
+	}()
+
+	s.signalStartMining()
+
+	return nil
+}
+
+// UpsertNodeTransaction accepts a transaction shared by a peer and adds it
+// to the mempool.
+func (s *State) UpsertNodeTransaction(tx transaction.BlockTx) error {
+	if err := s.checkExpiry(tx.Tx); err != nil {
+		return err
+	}
+
+	if err := s.mempool.Upsert(tx); err != nil {
+		return err
+	}
+
+	s.signalStartMining()
+
+	return nil
+}
+
+// MineNewBlock mines a block with the best transactions from the mempool
+// and commits it to the chain. Mining stops with an error when the context
+// is cancelled, like when a peer's block for the same number arrives.
+func (s *State) MineNewBlock(ctx context.Context) (block.Block, error) {
+	s.miningMu.RLock()
+	beneficiaryID, paused := s.beneficiaryID, s.miningPaused
+	s.miningMu.RUnlock()
+
+	if beneficiaryID == "" || paused {
+		return block.Block{}, ErrMiningDisabled
+	}
+
+	latestBlock := s.db.LatestBlock()
+	number := latestBlock.Header.Number + 1
+
+	// Expired transactions can't be included in the block.
+	if removed := s.mempool.DeleteExpired(number); removed > 0 {
+		s.evHandler("state: MineNewBlock: removed %d expired transactions", removed)
+	}
+
+	if s.mempool.Count() == 0 {
+		return block.Block{}, ErrNoTransactions
+	}
+
+	// Under POA a block can only be produced by an authority once its slot
+	// has come. An authority out of turn waits longer, so it only produces
+	// the block when the authority in turn hasn't.
+	var outOfTurn bool
+	if s.db.Consensus() == proof.ConsensusPOA {
+		signerID := acc.PublicKeyToAccountID(s.signerKey.PublicKey)
+
+		delay, err := proof.SlotDelay(s.genesis.Authorities, number, signerID, time.Duration(s.genesis.BlockInterval)*time.Second)
+		if err != nil {
+			return block.Block{}, err
+		}
+
+		if err := proof.WaitForSlot(ctx, latestBlock.Header, delay); err != nil {
+			return block.Block{}, err
+		}
+
+		outOfTurn = proof.Difficulty(s.genesis.Authorities, number, signerID) == proof.DifficultyOutOfTurn
+	}
+
+	ordering, err := block.ToOrderingPolicy(s.genesis.Ordering)
+	if err != nil {
+		return block.Block{}, err
+	}
+
+	difficulty, err := s.db.NextDifficulty(latestBlock)
+	if err != nil {
+		return block.Block{}, err
+	}
+	if outOfTurn {
+		difficulty = proof.DifficultyOutOfTurn
+	}
+
+	policy := block.BuildPolicy{
+		Ordering:      ordering,
+		GasLimit:      s.db.NextGasLimit(latestBlock, s.gasTarget),
+		MaxTrans:      int(s.genesis.TransPerBlock),
+		BeneficiaryID: beneficiaryID,
+		TimeStamp:     uint64(time.Now().UTC().UnixMilli()),
+		Difficulty:    difficulty,
+		MiningReward:  s.db.MiningReward(number),
+	}
+
+	candidate, err := block.BuildBlock(latestBlock, s.mempool.PickBest(0), policy)
+	if err != nil {
+		return block.Block{}, err
+	}
+
+	// The state roots are those of the accounts once the block is applied.
+	candidate, err = s.db.ExecuteBlock(candidate)
+	if err != nil {
+		return block.Block{}, err
+	}
+
+	s.evHandler("state: MineNewBlock: MINING: block[%d] trans[%d]", number, len(candidate.MerkleTree.Values()))
+
+	b, err := s.seal(ctx, candidate)
+	if err != nil {
+		return block.Block{}, err
+	}
+
+	// A peer's block may have been applied while we were mining, which
+	// makes this block invalid.
+	if err := s.db.ApplyBlock(b); err != nil {
+		return block.Block{}, err
+	}
+
+	s.evHandler("state: MineNewBlock: MINED: block[%d] hash[%s]", b.Header.Number, b.Hash())
+
+	return b, nil
+}
+
+// seal produces the proof the chain's consensus requires for the candidate
+// block.
+func (s *State) seal(ctx context.Context, candidate block.Block) (block.Block, error) {
+	switch s.db.Consensus() {
+	case proof.ConsensusPOA:
+		return proof.POA(candidate, s.genesis.Authorities, s.signerKey)
+
+	default:
+		b, work, err := proof.POW(ctx, candidate, s.miningWorkers)
+
+		s.workMu.Lock()
+		s.work = s.work.Add(work)
+		s.workMu.Unlock()
+
+		s.evHandler("state: MineNewBlock: MINING: attempts[%d] hashes/sec[%.0f]", work.Attempts, work.HashesPerSec())
+
+		return b, err
+	}
+}
+
+// ProcessProposedBlock applies a block mined by a peer. Any mining in
+// progress is cancelled since it's for a block that is no longer next. A
+// block from a forked chain triggers a sync with the longest chain.
+func (s *State) ProcessProposedBlock(b block.Block) error {
+	s.evHandler("state: ProcessProposedBlock: block[%d] hash[%s]", b.Header.Number, b.Hash())
+
+	s.signalCancelMining()
+
+	if err := s.db.ApplyBlock(b); err != nil {
+		if errors.Is(err, block.ErrChainForked) {
+			s.signalSync()
+		}
+		return err
+	}
+
+	// Drop the transactions that can't be included in the next block.
+	s.mempool.DeleteExpired(b.Header.Number + 1)
+
+	// Pick up mining again with the transactions left in the mempool.
+	s.signalStartMining()
+
+	return nil
+}
+
+// Sync brings the chain up to date with the peer holding the longest chain.
+func (s *State) Sync(ctx context.Context) error {
+	return s.gossip.Sync(ctx, s.db)
+}
+
+// =============================================================================
+
+// checkExpiry rejects a transaction that can't be included in the next
+// block.
+func (s *State) checkExpiry(tx transaction.Tx) error {
+	if tx.Expired(s.db.LatestBlock().Header.Number + 1) {
+		return fmt.Errorf("transaction invalid, expired at block %d", tx.ExpiresAt)
+	}
+
+	return nil
+}
+
+// signalStartMining asks the worker to start mining if there is one.
+func (s *State) signalStartMining() {
+	if s.Worker != nil {
+		s.Worker.SignalStartMining()
+	}
+}
+
+// signalCancelMining asks the worker to cancel mining if there is one.
+func (s *State) signalCancelMining() {
+	if s.Worker != nil {
+		s.Worker.SignalCancelMining()
+	}
+}
+
+// signalSync asks the worker to sync with the peers if there is one.
+func (s *State) signalSync() {
+	if s.Worker != nil {
+		s.Worker.SignalSync()
+	}
+}
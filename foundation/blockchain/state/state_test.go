@@ -0,0 +1,265 @@
+package state_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+	"github.com/dudakovict/blockchain/foundation/blockchain/chaintest"
+	"github.com/dudakovict/blockchain/foundation/blockchain/genesis"
+	"github.com/dudakovict/blockchain/foundation/blockchain/peer"
+	"github.com/dudakovict/blockchain/foundation/blockchain/proof"
+	"github.com/dudakovict/blockchain/foundation/blockchain/state"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage/memory"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+)
+
+const toID = acc.AccountID("0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76")
+
+// newState constructs a node without peers holding its chain in memory. The
+// account of the key for seed 1 starts with a balance of 1000.
+func newState(t *testing.T, gen genesis.Genesis, beneficiaryID acc.AccountID) *state.State {
+	t.Helper()
+
+	gen.ChainID = 1
+	gen.Difficulty = 2
+	gen.GasPrice = amount.New(1)
+	gen.Balances = map[string]amount.Amount{string(chaintest.AccountID(1)): amount.New(1000)}
+
+	cfg := state.Config{
+		BeneficiaryID: beneficiaryID,
+		SignerKey:     chaintest.Key(2),
+		MiningWorkers: 2,
+		Host:          "0.0.0.0:9080",
+		Storage:       memory.New(),
+		Genesis:       gen,
+		KnownPeers:    peer.NewPeerSet(),
+	}
+
+	st, err := state.New(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return st
+}
+
+// signedTx constructs a transfer of the value from the account of the key
+// for seed 1.
+func signedTx(t *testing.T, nonce uint64, value uint64, expiresAt uint64) transaction.SignedTx {
+	t.Helper()
+
+	tx, err := transaction.NewTx(1, nonce, chaintest.AccountID(1), toID, amount.New(value), amount.New(0), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tx.ExpiresAt = expiresAt
+
+	signed, err := tx.Sign(chaintest.Key(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return signed
+}
+
+// =============================================================================
+
+func Test_MineNewBlock(t *testing.T) {
+	st := newState(t, genesis.Genesis{}, chaintest.AccountID(2))
+
+	if _, err := st.MineNewBlock(context.Background()); !errors.Is(err, state.ErrNoTransactions) {
+		t.Fatalf("error: expected %v got %v", state.ErrNoTransactions, err)
+	}
+
+	if err := st.UpsertWalletTransaction(signedTx(t, 1, 100, 0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err := st.MineNewBlock(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if latest := st.DB().LatestBlock(); b.Header.Number != 1 || latest.Hash() != b.Hash() {
+		t.Errorf("error: expected block 1 to be the latest block got %d", latest.Header.Number)
+	}
+
+	if st.Mempool().Count() != 0 {
+		t.Errorf("error: expected the mined transaction to leave the mempool")
+	}
+
+	if account, _ := st.DB().Query(toID); account.Balance.Cmp(amount.New(100)) != 0 {
+		t.Errorf("error: expected the transfer of 100 to be applied got %s", account.Balance)
+	}
+
+	if st.MiningWork().Attempts == 0 {
+		t.Errorf("error: expected the mining work to be recorded")
+	}
+}
+
+// Test_UpsertWalletTransaction checks the transactions that can't be
+// included in the next block are kept out of the mempool.
+func Test_UpsertWalletTransaction(t *testing.T) {
+	st := newState(t, genesis.Genesis{}, chaintest.AccountID(2))
+
+	if err := st.UpsertWalletTransaction(signedTx(t, 1, 100, 0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := st.MineNewBlock(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	unknown := signedTx(t, 2, 100, 0)
+	unknown.Type = "bogus"
+
+	type table struct {
+		testCaseID int
+		tx         transaction.SignedTx
+		expected   string
+	}
+
+	tt := []table{
+		{testCaseID: 0, tx: signedTx(t, 2, 100, 1), expected: "expired at block 1"},
+		{testCaseID: 1, tx: unknown, expected: "unknown transaction type"},
+		{testCaseID: 2, tx: signedTx(t, 2, 100, 2)},
+	}
+
+	for _, tst := range tt {
+		err := st.UpsertWalletTransaction(tst.tx)
+
+		if tst.expected == "" {
+			if err != nil {
+				t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			}
+			continue
+		}
+
+		if err == nil || !strings.Contains(err.Error(), tst.expected) {
+			t.Errorf("[case:%d] error: expected an error about %q got %v", tst.testCaseID, tst.expected, err)
+		}
+	}
+
+	if st.Mempool().Count() != 1 {
+		t.Errorf("error: expected 1 transaction in the mempool got %d", st.Mempool().Count())
+	}
+}
+
+// Test_PauseMining checks a paused node doesn't mine and a new beneficiary
+// receives the reward for the blocks mined after it is set.
+func Test_PauseMining(t *testing.T) {
+	st := newState(t, genesis.Genesis{}, chaintest.AccountID(2))
+
+	if err := st.UpsertWalletTransaction(signedTx(t, 1, 100, 0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	st.PauseMining()
+	if _, err := st.MineNewBlock(context.Background()); !errors.Is(err, state.ErrMiningDisabled) {
+		t.Errorf("error: expected %v got %v", state.ErrMiningDisabled, err)
+	}
+
+	if err := st.SetBeneficiaryID(""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	st.ResumeMining()
+	if _, err := st.MineNewBlock(context.Background()); !errors.Is(err, state.ErrMiningDisabled) {
+		t.Errorf("error: expected mining to stay disabled without a beneficiary got %v", err)
+	}
+
+	if err := st.SetBeneficiaryID(chaintest.AccountID(3)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err := st.MineNewBlock(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b.Header.BeneficiaryID != chaintest.AccountID(3) {
+		t.Errorf("error: expected the block mined for %s got %s", chaintest.AccountID(3), b.Header.BeneficiaryID)
+	}
+}
+
+// Test_MineNewBlockPOA checks an authority signs a block out of turn with
+// less weight and a node that isn't an authority doesn't produce blocks.
+func Test_MineNewBlockPOA(t *testing.T) {
+	gen := genesis.Genesis{
+		Consensus:   string(proof.ConsensusPOA),
+		Authorities: []acc.AccountID{chaintest.AccountID(3), chaintest.AccountID(2)},
+	}
+
+	st := newState(t, gen, chaintest.AccountID(2))
+
+	// The second authority signs the odd blocks in turn and, with the first
+	// authority offline, the even blocks out of turn.
+	for nonce := uint64(1); nonce <= 2; nonce++ {
+		if err := st.UpsertWalletTransaction(signedTx(t, nonce, 100, 0)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		b, err := st.MineNewBlock(context.Background())
+		if err != nil {
+			t.Fatalf("[block:%d] unexpected error: %v", nonce, err)
+		}
+
+		expected := proof.DifficultyInTurn
+		if nonce == 2 {
+			expected = proof.DifficultyOutOfTurn
+		}
+		if b.Header.Difficulty != expected {
+			t.Errorf("[block:%d] error: expected difficulty %d got %d", nonce, expected, b.Header.Difficulty)
+		}
+	}
+
+	gen.Authorities = []acc.AccountID{chaintest.AccountID(3)}
+	st = newState(t, gen, chaintest.AccountID(2))
+
+	if err := st.UpsertWalletTransaction(signedTx(t, 1, 100, 0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := st.MineNewBlock(context.Background()); !errors.Is(err, proof.ErrNotAuthority) {
+		t.Errorf("error: expected %v got %v", proof.ErrNotAuthority, err)
+	}
+}
+
+// Test_Recover checks a panic in a goroutine running node operations is
+// reported with its stack trace instead of taking the node down.
+func Test_Recover(t *testing.T) {
+	type report struct {
+		op    string
+		rec   any
+		trace string
+	}
+	reports := make(chan report, 1)
+
+	cfg := state.Config{
+		Storage:    memory.New(),
+		Genesis:    genesis.Genesis{ChainID: 1},
+		KnownPeers: peer.NewPeerSet(),
+		PanicHandler: func(op string, rec any, trace []byte) {
+			reports <- report{op: op, rec: rec, trace: string(trace)}
+		},
+	}
+
+	st, err := state.New(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	go func() {
+		defer st.Recover("test: operation")
+		panic("malformed message")
+	}()
+
+	r := <-reports
+	if r.op != "test: operation" || r.rec != "malformed message" {
+		t.Errorf("error: expected the panic of the operation got %q: %v", r.op, r.rec)
+	}
+	if !strings.Contains(r.trace, "Test_Recover") {
+		t.Errorf("error: expected the stack trace of the panic got %s", r.trace)
+	}
+}
@@ -0,0 +1,295 @@
+// Package batch implements the storage interface by grouping block writes
+// into batches that are committed to another storage together. Blocks are
+// appended to a write-ahead log and synced first so a batch that hasn't been
+// committed yet survives a crash.
+package batch
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage"
+)
+
+// Config represents the settings for grouping writes. A batch is committed
+// when it holds Size blocks or when Interval has passed, whichever comes
+// first.
+type Config struct {
+	Size     int
+	Interval time.Duration
+	WALPath  string
+}
+
+// Batch represents the storage implementation that groups writes.
+type Batch struct {
+	mu       sync.Mutex
+	store    storage.Storage
+	size     int
+	walPath  string
+	wal      *os.File
+	pending  []storage.BlockData
+	flushErr error
+	shut     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New constructs a Batch value that commits the grouped writes to the
+// specified storage. Blocks left in the write-ahead log by a crash are
+// committed before the value is returned.
+func New(store storage.Storage, cfg Config) (*Batch, error) {
+	if cfg.Size < 1 {
+		cfg.Size = 1
+	}
+
+	if err := recoverWAL(store, cfg.WALPath); err != nil {
+		return nil, fmt.Errorf("recovering write-ahead log: %w", err)
+	}
+
+	wal, err := os.OpenFile(cfg.WALPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	b := Batch{
+		store:   store,
+		size:    cfg.Size,
+		walPath: cfg.WALPath,
+		wal:     wal,
+		shut:    make(chan struct{}),
+	}
+
+	if cfg.Interval > 0 {
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			b.flushOperations(cfg.Interval)
+		}()
+	}
+
+	return &b, nil
+}
+
+// Close commits the pending blocks and closes the underlying storage.
+func (b *Batch) Close() error {
+	close(b.shut)
+	b.wg.Wait()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.flush(); err != nil {
+		return err
+	}
+
+	if err := b.wal.Close(); err != nil {
+		return err
+	}
+
+	return b.store.Close()
+}
+
+// Write appends the block to the write-ahead log and adds it to the pending
+// batch. The log is synced before Write returns, so the block survives a
+// crash even though the batch it belongs to hasn't been committed. The batch
+// is committed once it is full.
+func (b *Batch) Write(blockData storage.BlockData) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// A batch that failed to commit in the background is reported once.
+	// Its blocks stay pending and are committed with the next batch.
+	if err := b.flushErr; err != nil {
+		b.flushErr = nil
+		return fmt.Errorf("committing pending blocks: %w", err)
+	}
+
+	data, err := json.Marshal(blockData)
+	if err != nil {
+		return err
+	}
+
+	if _, err := b.wal.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	if err := b.wal.Sync(); err != nil {
+		return err
+	}
+
+	b.pending = append(b.pending, blockData)
+
+	if len(b.pending) >= b.size {
+		return b.flush()
+	}
+
+	return nil
+}
+
+// GetBlock returns the specified block from the pending batch or the
+// underlying storage.
+func (b *Batch) GetBlock(num uint64) (storage.BlockData, error) {
+	b.mu.Lock()
+	for i := len(b.pending) - 1; i >= 0; i-- {
+		if b.pending[i].Header.Number == num {
+			blockData := b.pending[i]
+			b.mu.Unlock()
+			return blockData, nil
+		}
+	}
+	b.mu.Unlock()
+
+	return b.store.GetBlock(num)
+}
+
+// ForEach commits the pending blocks and then calls the function for every
+// block in the underlying storage.
+func (b *Batch) ForEach(fn func(blockData storage.BlockData) error) error {
+	if err := b.Flush(); err != nil {
+		return err
+	}
+
+	return b.store.ForEach(fn)
+}
+
+// Truncate commits the pending blocks and then removes every block after the
+// specified block number from the underlying storage.
+func (b *Batch) Truncate(num uint64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.flush(); err != nil {
+		return err
+	}
+
+	return b.store.Truncate(num)
+}
+
+// Reset drops the pending blocks and clears the underlying storage.
+func (b *Batch) Reset() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.pending = nil
+	b.flushErr = nil
+
+	if err := b.truncateWAL(); err != nil {
+		return err
+	}
+
+	return b.store.Reset()
+}
+
+// Flush commits the pending blocks to the underlying storage.
+func (b *Batch) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.flush()
+}
+
+// =============================================================================
+
+// flushOperations commits the pending blocks on every interval until the
+// batch is closed.
+func (b *Batch) flushOperations(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.mu.Lock()
+			if err := b.flush(); err != nil {
+				b.flushErr = err
+			}
+			b.mu.Unlock()
+
+		case <-b.shut:
+			return
+		}
+	}
+}
+
+// flush writes the pending blocks to the underlying storage, syncs the
+// storage once for the whole batch and then truncates the write-ahead log.
+// The lock must be held by the caller.
+func (b *Batch) flush() error {
+	if len(b.pending) == 0 {
+		return nil
+	}
+
+	for _, blockData := range b.pending {
+		if err := b.store.Write(blockData); err != nil {
+			return err
+		}
+	}
+
+	if err := syncStore(b.store); err != nil {
+		return err
+	}
+
+	b.pending = nil
+
+	return b.truncateWAL()
+}
+
+// truncateWAL removes every entry from the write-ahead log.
+func (b *Batch) truncateWAL() error {
+	if err := b.wal.Truncate(0); err != nil {
+		return err
+	}
+
+	_, err := b.wal.Seek(0, io.SeekStart)
+	return err
+}
+
+// recoverWAL writes the blocks found in the write-ahead log to the storage
+// and syncs them, since the log is truncated once the batch is constructed.
+// An entry that was only partially written when the node stopped ends the
+// recovery, since Write never returned for it.
+func recoverWAL(store storage.Storage, walPath string) error {
+	f, err := os.Open(walPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	for scanner.Scan() {
+		var blockData storage.BlockData
+		if err := json.Unmarshal(scanner.Bytes(), &blockData); err != nil {
+			break
+		}
+
+		if err := store.Write(blockData); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return syncStore(store)
+}
+
+// syncStore makes the blocks written to the storage durable, if the storage
+// needs to be told to.
+func syncStore(store storage.Storage) error {
+	if syncer, ok := store.(storage.Syncer); ok {
+		return syncer.Sync()
+	}
+
+	return nil
+}
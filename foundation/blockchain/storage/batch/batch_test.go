@@ -0,0 +1,306 @@
+package batch_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage/batch"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage/disk"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage/memory"
+)
+
+// syncStore records how often the storage is synced.
+type syncStore struct {
+	*memory.Memory
+	syncs int
+}
+
+// Sync implements the storage.Syncer interface.
+func (s *syncStore) Sync() error {
+	s.syncs++
+	return nil
+}
+
+// failStore fails the specified number of writes before it recovers.
+type failStore struct {
+	*memory.Memory
+	failures atomic.Int32
+}
+
+// Write implements the storage.Storage interface.
+func (s *failStore) Write(blockData storage.BlockData) error {
+	if s.failures.Add(-1) >= 0 {
+		return errors.New("disk full")
+	}
+
+	return s.Memory.Write(blockData)
+}
+
+// blockData constructs the stored form of a block with the specified number.
+func blockData(num uint64) storage.BlockData {
+	return storage.BlockData{Hash: "0x" + string(rune('a'+num)), Header: block.BlockHeader{Number: num}}
+}
+
+// count returns the number of blocks held by the storage.
+func count(t *testing.T, store storage.Storage) int {
+	t.Helper()
+
+	var n int
+	if err := store.ForEach(func(storage.BlockData) error { n++; return nil }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return n
+}
+
+// =============================================================================
+
+func Test_Write(t *testing.T) {
+	type table struct {
+		testCaseID int
+		size       int
+		writes     int
+		committed  int
+		syncs      int
+	}
+
+	tt := []table{
+		{testCaseID: 0, size: 3, writes: 2, committed: 0, syncs: 0},
+		{testCaseID: 1, size: 3, writes: 3, committed: 3, syncs: 1},
+		{testCaseID: 2, size: 3, writes: 7, committed: 6, syncs: 2},
+		{testCaseID: 3, size: 0, writes: 2, committed: 2, syncs: 2},
+	}
+
+	for _, tst := range tt {
+		store := syncStore{Memory: memory.New()}
+		wal := filepath.Join(t.TempDir(), "blocks.wal")
+
+		b, err := batch.New(&store, batch.Config{Size: tst.size, WALPath: wal})
+		if err != nil {
+			t.Fatalf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+
+		for num := uint64(1); num <= uint64(tst.writes); num++ {
+			if err := b.Write(blockData(num)); err != nil {
+				t.Fatalf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			}
+		}
+
+		if n := count(t, store.Memory); n != tst.committed {
+			t.Errorf("[case:%d] error: expected %d committed blocks got %d", tst.testCaseID, tst.committed, n)
+		}
+		if store.syncs != tst.syncs {
+			t.Errorf("[case:%d] error: expected %d syncs got %d", tst.testCaseID, tst.syncs, store.syncs)
+		}
+
+		// Pending blocks are read from the batch before they are committed.
+		for num := uint64(1); num <= uint64(tst.writes); num++ {
+			bd, err := b.GetBlock(num)
+			if err != nil {
+				t.Errorf("[case:%d] error: unexpected error reading block %d: %v", tst.testCaseID, num, err)
+				continue
+			}
+			if bd.Hash != blockData(num).Hash {
+				t.Errorf("[case:%d] error: expected hash %s got %s", tst.testCaseID, blockData(num).Hash, bd.Hash)
+			}
+		}
+
+		if err := b.Close(); err != nil {
+			t.Fatalf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+
+		if n := count(t, store.Memory); n != tst.writes {
+			t.Errorf("[case:%d] error: expected %d blocks after close got %d", tst.testCaseID, tst.writes, n)
+		}
+
+		// A clean close leaves nothing in the log.
+		if data, err := os.ReadFile(wal); err != nil || len(data) != 0 {
+			t.Errorf("[case:%d] error: expected an empty log got %d bytes: %v", tst.testCaseID, len(data), err)
+		}
+	}
+}
+
+func Test_RecoverWAL(t *testing.T) {
+	const (
+		entry1  = `{"hash":"0xb","block":{"number":1},"trans":null}` + "\n"
+		entry2  = `{"hash":"0xc","block":{"number":2},"trans":null}` + "\n"
+		partial = `{"hash":"0xd","blo`
+	)
+
+	type table struct {
+		testCaseID int
+		wal        *string
+		recovered  int
+	}
+
+	str := func(s string) *string { return &s }
+
+	tt := []table{
+		{testCaseID: 0, wal: nil, recovered: 0},
+		{testCaseID: 1, wal: str(""), recovered: 0},
+		{testCaseID: 2, wal: str(entry1 + entry2), recovered: 2},
+		{testCaseID: 3, wal: str(entry1 + entry2 + partial), recovered: 2},
+		{testCaseID: 4, wal: str(partial), recovered: 0},
+	}
+
+	for _, tst := range tt {
+		wal := filepath.Join(t.TempDir(), "blocks.wal")
+		if tst.wal != nil {
+			if err := os.WriteFile(wal, []byte(*tst.wal), 0600); err != nil {
+				t.Fatalf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			}
+		}
+
+		store := syncStore{Memory: memory.New()}
+		b, err := batch.New(&store, batch.Config{Size: 10, WALPath: wal})
+		if err != nil {
+			t.Fatalf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+
+		if n := count(t, store.Memory); n != tst.recovered {
+			t.Errorf("[case:%d] error: expected %d recovered blocks got %d", tst.testCaseID, tst.recovered, n)
+		}
+		if store.syncs == 0 && tst.wal != nil {
+			t.Errorf("[case:%d] error: expected the recovered blocks to be synced", tst.testCaseID)
+		}
+
+		// The log starts over once the blocks are recovered.
+		if data, err := os.ReadFile(wal); err != nil || len(data) != 0 {
+			t.Errorf("[case:%d] error: expected an empty log got %d bytes: %v", tst.testCaseID, len(data), err)
+		}
+
+		if err := b.Close(); err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+	}
+}
+
+// Test_CrashOnDisk writes blocks that never get committed and then opens the
+// chain again as a node would after a crash.
+func Test_CrashOnDisk(t *testing.T) {
+	dir := t.TempDir()
+	chain := filepath.Join(dir, "chain")
+	wal := filepath.Join(dir, "blocks.wal")
+
+	d, err := disk.New(chain)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err := batch.New(d, batch.Config{Size: 10, WALPath: wal})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for num := uint64(1); num <= 3; num++ {
+		if err := b.Write(blockData(num)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// The batch is abandoned without being closed.
+	d, err = disk.New(chain)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n := count(t, d); n != 0 {
+		t.Fatalf("error: expected no blocks committed before the crash got %d", n)
+	}
+
+	b, err = batch.New(d, batch.Config{Size: 10, WALPath: wal})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer b.Close()
+
+	if n := count(t, b); n != 3 {
+		t.Fatalf("error: expected 3 blocks recovered got %d", n)
+	}
+
+	for num := uint64(1); num <= 3; num++ {
+		bd, err := d.GetBlock(num)
+		if err != nil {
+			t.Errorf("error: unexpected error reading block %d: %v", num, err)
+			continue
+		}
+		if bd.Hash != blockData(num).Hash {
+			t.Errorf("error: expected hash %s got %s", blockData(num).Hash, bd.Hash)
+		}
+	}
+}
+
+func Test_TruncateAndReset(t *testing.T) {
+	store := memory.New()
+
+	b, err := batch.New(store, batch.Config{Size: 10, WALPath: filepath.Join(t.TempDir(), "blocks.wal")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer b.Close()
+
+	for num := uint64(1); num <= 5; num++ {
+		if err := b.Write(blockData(num)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := b.Truncate(2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := count(t, b); n != 2 {
+		t.Errorf("error: expected 2 blocks after truncating got %d", n)
+	}
+
+	if err := b.Write(blockData(3)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := b.Reset(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := count(t, b); n != 0 {
+		t.Errorf("error: expected no blocks after a reset got %d", n)
+	}
+	if _, err := b.GetBlock(3); err == nil {
+		t.Errorf("error: expected the pending block to be dropped by the reset")
+	}
+}
+
+func Test_FlushFailure(t *testing.T) {
+	store := failStore{Memory: memory.New()}
+	store.failures.Store(1)
+
+	b, err := batch.New(&store, batch.Config{Size: 10, Interval: time.Millisecond, WALPath: filepath.Join(t.TempDir(), "blocks.wal")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer b.Close()
+
+	if err := b.Write(blockData(1)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Let the background commit fail once and then succeed.
+	time.Sleep(20 * time.Millisecond)
+
+	if err := b.Write(blockData(2)); err == nil {
+		t.Fatalf("error: expected the failed commit to be reported")
+	}
+
+	// Once reported the failure doesn't block the writes that follow.
+	if err := b.Write(blockData(2)); err != nil {
+		t.Fatalf("error: expected the write to succeed once the failure was reported: %v", err)
+	}
+	if err := b.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := count(t, store.Memory); n != 2 {
+		t.Errorf("error: expected 2 committed blocks got %d", n)
+	}
+}
@@ -0,0 +1,178 @@
+// Package disk implements the storage interface by writing each block to its
+// own JSON file on disk.
+package disk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage"
+)
+
+// Disk represents the serialization implementation for reading and storing
+// blocks in their own separate files on disk.
+type Disk struct {
+	dbPath string
+	mu     sync.Mutex
+	dirty  map[string]struct{}
+}
+
+// New constructs a Disk value for use, creating the directory if it doesn't
+// exist.
+func New(dbPath string) (*Disk, error) {
+	if err := os.MkdirAll(dbPath, 0755); err != nil {
+		return nil, err
+	}
+
+	d := Disk{
+		dbPath: dbPath,
+		dirty:  make(map[string]struct{}),
+	}
+
+	return &d, nil
+}
+
+// Close in this implementation has nothing to do since a new file is
+// written to disk for each new block and then immediately closed.
+func (d *Disk) Close() error {
+	return nil
+}
+
+// Write takes the specified block and stores it on disk in a file labeled
+// with the block number. The file is left to the operating system to flush,
+// call Sync for the block to survive a crash.
+func (d *Disk) Write(blockData storage.BlockData) error {
+	data, err := json.MarshalIndent(blockData, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash never leaves a partially
+	// written block behind.
+	path := d.getPath(blockData.Header.Number)
+	tmp := path + ".tmp"
+
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	d.dirty[path] = struct{}{}
+	d.mu.Unlock()
+
+	return nil
+}
+
+// Sync flushes the blocks written since the last sync to stable storage,
+// along with the directory entries that name them.
+func (d *Disk) Sync() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.dirty) == 0 {
+		return nil
+	}
+
+	for path := range d.dirty {
+		if err := syncPath(path); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return err
+		}
+	}
+
+	if err := syncPath(d.dbPath); err != nil {
+		return err
+	}
+
+	d.dirty = make(map[string]struct{})
+
+	return nil
+}
+
+// GetBlock searches the blockchain on disk to locate and return the
+// contents of the specified block by number.
+func (d *Disk) GetBlock(num uint64) (storage.BlockData, error) {
+	data, err := os.ReadFile(d.getPath(num))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return storage.BlockData{}, storage.ErrNotFound
+		}
+		return storage.BlockData{}, err
+	}
+
+	var blockData storage.BlockData
+	if err := json.Unmarshal(data, &blockData); err != nil {
+		return storage.BlockData{}, fmt.Errorf("block %d: %w", num, err)
+	}
+
+	return blockData, nil
+}
+
+// ForEach calls the function for every block on disk in order, starting with
+// block 1 and stopping at the first block that doesn't exist.
+func (d *Disk) ForEach(fn func(blockData storage.BlockData) error) error {
+	for num := uint64(1); ; num++ {
+		blockData, err := d.GetBlock(num)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		if err := fn(blockData); err != nil {
+			return err
+		}
+	}
+}
+
+// Truncate removes every block on disk after the specified block number.
+func (d *Disk) Truncate(num uint64) error {
+	for next := num + 1; ; next++ {
+		err := os.Remove(d.getPath(next))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// Reset will clear out the blockchain on disk.
+func (d *Disk) Reset() error {
+	if err := os.RemoveAll(d.dbPath); err != nil {
+		return err
+	}
+
+	return os.MkdirAll(d.dbPath, 0755)
+}
+
+// syncPath flushes the file or directory to stable storage.
+func syncPath(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return f.Sync()
+}
+
+// getPath forms the path to the specified block.
+func (d *Disk) getPath(blockNum uint64) string {
+	name := strconv.FormatUint(blockNum, 10)
+	return filepath.Join(d.dbPath, fmt.Sprintf("%s.json", name))
+}
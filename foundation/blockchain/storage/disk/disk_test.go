@@ -0,0 +1,78 @@
+package disk_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage/disk"
+)
+
+func Test_Write(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "chain")
+
+	d, err := disk.New(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for num := uint64(1); num <= 3; num++ {
+		if err := d.Write(storage.BlockData{Header: block.BlockHeader{Number: num}}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// Each block is in its own file and no temporary files are left behind.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 3 || entries[0].Name() != "1.json" {
+		t.Errorf("error: expected 3 block files got %v", entries)
+	}
+
+	// A block truncated since it was written is skipped by the sync.
+	if err := d.Truncate(2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := d.Sync(); err != nil {
+		t.Errorf("error: unexpected error syncing a truncated block: %v", err)
+	}
+}
+
+func Test_GetBlock(t *testing.T) {
+	dir := t.TempDir()
+
+	d, err := disk.New(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := d.Write(storage.BlockData{Header: block.BlockHeader{Number: 1}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "2.json"), []byte(`{"hash":`), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := d.GetBlock(3); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("error: expected %v got %v", storage.ErrNotFound, err)
+	}
+
+	if _, err := d.GetBlock(2); err == nil || errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("error: expected the damaged block to be reported got %v", err)
+	}
+
+	// Reading the chain stops at the damaged block instead of skipping it.
+	var visited int
+	err = d.ForEach(func(storage.BlockData) error {
+		visited++
+		return nil
+	})
+	if err == nil || visited != 1 {
+		t.Errorf("error: expected to stop at block 2 after 1 block got %d: %v", visited, err)
+	}
+}
@@ -0,0 +1,94 @@
+// Package memory implements the storage interface by keeping the blocks in
+// memory. It is useful for tooling and tests that shouldn't touch the disk.
+package memory
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage"
+)
+
+// Memory represents the storage implementation for keeping blocks in memory.
+type Memory struct {
+	mu     sync.RWMutex
+	blocks []storage.BlockData
+}
+
+// New constructs a Memory value for use.
+func New() *Memory {
+	return &Memory{}
+}
+
+// Close in this implementation has nothing to do.
+func (m *Memory) Close() error {
+	return nil
+}
+
+// Write stores the specified block. Writing a block that already exists
+// replaces it and every block after it.
+func (m *Memory) Write(blockData storage.BlockData) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	num := blockData.Header.Number
+	if num >= 1 && num <= uint64(len(m.blocks)) {
+		m.blocks = m.blocks[:num-1]
+	}
+
+	if exp := uint64(len(m.blocks)) + 1; num != exp {
+		return fmt.Errorf("block is out of order, got %d, exp %d", num, exp)
+	}
+
+	m.blocks = append(m.blocks, blockData)
+	return nil
+}
+
+// GetBlock returns the contents of the specified block by number.
+func (m *Memory) GetBlock(num uint64) (storage.BlockData, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if num == 0 || num > uint64(len(m.blocks)) {
+		return storage.BlockData{}, storage.ErrNotFound
+	}
+
+	return m.blocks[num-1], nil
+}
+
+// ForEach calls the function for every block in order.
+func (m *Memory) ForEach(fn func(blockData storage.BlockData) error) error {
+	m.mu.RLock()
+	blocks := make([]storage.BlockData, len(m.blocks))
+	copy(blocks, m.blocks)
+	m.mu.RUnlock()
+
+	for _, blockData := range blocks {
+		if err := fn(blockData); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Truncate removes every block after the specified block number.
+func (m *Memory) Truncate(num uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if num < uint64(len(m.blocks)) {
+		m.blocks = m.blocks[:num]
+	}
+
+	return nil
+}
+
+// Reset removes every block.
+func (m *Memory) Reset() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.blocks = nil
+	return nil
+}
@@ -0,0 +1,59 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage/memory"
+)
+
+func Test_Write(t *testing.T) {
+	blockData := func(num uint64, hash string) storage.BlockData {
+		return storage.BlockData{Hash: hash, Header: block.BlockHeader{Number: num}}
+	}
+
+	type table struct {
+		testCaseID int
+		block      storage.BlockData
+		success    bool
+		blocks     int
+	}
+
+	tt := []table{
+		{testCaseID: 0, block: blockData(1, "0x1a"), success: true, blocks: 1},
+		{testCaseID: 1, block: blockData(2, "0x2a"), success: true, blocks: 2},
+		{testCaseID: 2, block: blockData(3, "0x3a"), success: true, blocks: 3},
+		{testCaseID: 3, block: blockData(5, "0x5a"), blocks: 3},
+		{testCaseID: 4, block: blockData(0, "0x0a"), blocks: 3},
+
+		// Writing an existing block replaces it and the blocks after it.
+		{testCaseID: 5, block: blockData(2, "0x2b"), success: true, blocks: 2},
+	}
+
+	m := memory.New()
+
+	for _, tst := range tt {
+		err := m.Write(tst.block)
+
+		if tst.success && err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+		if !tst.success && err == nil {
+			t.Errorf("[case:%d] error: expected block %d to be out of order", tst.testCaseID, tst.block.Header.Number)
+		}
+
+		var blocks int
+		m.ForEach(func(storage.BlockData) error {
+			blocks++
+			return nil
+		})
+		if blocks != tst.blocks {
+			t.Errorf("[case:%d] error: expected %d blocks got %d", tst.testCaseID, tst.blocks, blocks)
+		}
+	}
+
+	if bd, _ := m.GetBlock(2); bd.Hash != "0x2b" {
+		t.Errorf("error: expected block 2 to be replaced got %s", bd.Hash)
+	}
+}
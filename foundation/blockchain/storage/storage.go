@@ -0,0 +1,72 @@
+// Package storage defines the behavior required to persist the blocks of the
+// blockchain and the format blocks are stored in.
+package storage
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+)
+
+// ErrNotFound is returned when a block doesn't exist in storage.
+var ErrNotFound = errors.New("block not found")
+
+// Storage represents the behavior required to store and read the blocks of
+// the blockchain. Blocks are written in order starting with block 1.
+// Truncate removes every block after the specified block number.
+type Storage interface {
+	Write(blockData BlockData) error
+	GetBlock(num uint64) (BlockData, error)
+	ForEach(fn func(blockData BlockData) error) error
+	Truncate(num uint64) error
+	Close() error
+	Reset() error
+}
+
+// Syncer is implemented by storages whose writes aren't durable until they
+// are synced, so callers that need the blocks to survive a crash can ask
+// for it.
+type Syncer interface {
+	Sync() error
+}
+
+// =============================================================================
+
+// BlockData represents what can be serialized to storage for a block.
+type BlockData struct {
+	Hash   string                `json:"hash"`
+	Header block.BlockHeader     `json:"block"`
+	Trans  []transaction.BlockTx `json:"trans"`
+}
+
+// NewBlockData constructs the block data that is stored for the block.
+func NewBlockData(b block.Block) BlockData {
+	blockData := BlockData{
+		Hash:   b.Hash(),
+		Header: b.Header,
+		Trans:  b.MerkleTree.Values(),
+	}
+
+	return blockData
+}
+
+// ToBlock converts stored block data back into a block. The transactions
+// and hash are checked against the header so a damaged block isn't used.
+func ToBlock(blockData BlockData) (block.Block, error) {
+	b, err := block.New(blockData.Header, blockData.Trans)
+	if err != nil {
+		return block.Block{}, err
+	}
+
+	if root := b.MerkleTree.RootHex(); root != b.Header.TransRoot {
+		return block.Block{}, fmt.Errorf("block %d transaction root doesn't match, got %s, exp %s", b.Header.Number, root, b.Header.TransRoot)
+	}
+
+	if hash := b.Hash(); hash != blockData.Hash {
+		return block.Block{}, fmt.Errorf("block %d hash doesn't match, got %s, exp %s", b.Header.Number, hash, blockData.Hash)
+	}
+
+	return b, nil
+}
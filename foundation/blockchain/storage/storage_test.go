@@ -0,0 +1,187 @@
+package storage_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/chaintest"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage/disk"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage/memory"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+)
+
+// blockData constructs the stored form of a block with the specified number.
+func blockData(num uint64) storage.BlockData {
+	return storage.BlockData{Hash: "0x" + string(rune('a'+num)), Header: block.BlockHeader{Number: num}}
+}
+
+// numbers returns the numbers of the blocks held by the storage in the order
+// they are visited.
+func numbers(t *testing.T, store storage.Storage) []uint64 {
+	t.Helper()
+
+	var nums []uint64
+	err := store.ForEach(func(bd storage.BlockData) error {
+		nums = append(nums, bd.Header.Number)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return nums
+}
+
+// =============================================================================
+
+// Test_Storage runs the same checks against every storage implementation
+// that keeps the blocks itself.
+func Test_Storage(t *testing.T) {
+	type table struct {
+		testCaseID int
+		name       string
+		store      func() (storage.Storage, error)
+	}
+
+	tt := []table{
+		{
+			testCaseID: 0,
+			name:       "memory",
+			store:      func() (storage.Storage, error) { return memory.New(), nil },
+		},
+		{
+			testCaseID: 1,
+			name:       "disk",
+			store:      func() (storage.Storage, error) { return disk.New(t.TempDir()) },
+		},
+	}
+
+	for _, tst := range tt {
+		store, err := tst.store()
+		if err != nil {
+			t.Fatalf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+
+		for num := uint64(1); num <= 4; num++ {
+			if err := store.Write(blockData(num)); err != nil {
+				t.Fatalf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			}
+		}
+
+		if syncer, ok := store.(storage.Syncer); ok {
+			if err := syncer.Sync(); err != nil {
+				t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			}
+		}
+
+		bd, err := store.GetBlock(3)
+		if err != nil || bd.Hash != blockData(3).Hash {
+			t.Errorf("[case:%d] error: expected block 3 with hash %s got %s: %v", tst.testCaseID, blockData(3).Hash, bd.Hash, err)
+		}
+
+		if _, err := store.GetBlock(5); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("[case:%d] error: expected %v got %v", tst.testCaseID, storage.ErrNotFound, err)
+		}
+
+		if got := numbers(t, store); len(got) != 4 || got[0] != 1 || got[3] != 4 {
+			t.Errorf("[case:%d] error: expected blocks 1 to 4 in order got %v", tst.testCaseID, got)
+		}
+
+		if err := store.Truncate(2); err != nil {
+			t.Fatalf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+		if got := numbers(t, store); len(got) != 2 {
+			t.Errorf("[case:%d] error: expected 2 blocks after truncating got %v", tst.testCaseID, got)
+		}
+		if _, err := store.GetBlock(3); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("[case:%d] error: expected block 3 to be truncated got %v", tst.testCaseID, err)
+		}
+
+		// The chain can grow again from the truncated block.
+		if err := store.Write(blockData(3)); err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+
+		if err := store.Reset(); err != nil {
+			t.Fatalf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+		if got := numbers(t, store); len(got) != 0 {
+			t.Errorf("[case:%d] error: expected no blocks after a reset got %v", tst.testCaseID, got)
+		}
+
+		if err := store.Close(); err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+	}
+}
+
+func Test_ToBlock(t *testing.T) {
+	pk := chaintest.Key(1)
+
+	tx, err := transaction.NewTx(1, 1, acc.PublicKeyToAccountID(pk.PublicKey), "0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76", amount.New(10), amount.New(1), []byte("data"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	signed, err := tx.Sign(pk)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err := block.BuildBlock(block.Block{}, []transaction.BlockTx{transaction.NewBlockTx(signed, amount.New(1), 1)}, block.BuildPolicy{BeneficiaryID: tx.ToID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	type table struct {
+		testCaseID int
+		change     func(bd *storage.BlockData)
+		success    bool
+	}
+
+	tt := []table{
+		{testCaseID: 0, change: func(bd *storage.BlockData) {}, success: true},
+		{testCaseID: 1, change: func(bd *storage.BlockData) { bd.Hash = "0x00" }},
+		{testCaseID: 2, change: func(bd *storage.BlockData) { bd.Header.TransRoot = "0x00" }},
+		{testCaseID: 3, change: func(bd *storage.BlockData) { bd.Trans[0].Value = amount.New(11) }},
+		{testCaseID: 4, change: func(bd *storage.BlockData) { bd.Trans = nil }},
+	}
+
+	for _, tst := range tt {
+
+		// Round trip the block through JSON as a storage would.
+		data, err := json.Marshal(storage.NewBlockData(b))
+		if err != nil {
+			t.Fatalf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+
+		var bd storage.BlockData
+		if err := json.Unmarshal(data, &bd); err != nil {
+			t.Fatalf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+
+		tst.change(&bd)
+
+		got, err := storage.ToBlock(bd)
+
+		if !tst.success {
+			if err == nil {
+				t.Errorf("[case:%d] error: expected the damaged block to be rejected", tst.testCaseID)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			continue
+		}
+		if got.Hash() != b.Hash() {
+			t.Errorf("[case:%d] error: expected hash %s got %s", tst.testCaseID, b.Hash(), got.Hash())
+		}
+	}
+}
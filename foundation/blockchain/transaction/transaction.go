@@ -10,24 +10,75 @@ import (
 	"time"
 
 	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
 	"github.com/dudakovict/blockchain/foundation/blockchain/signature"
 )
 
+// Set of transaction types supported by the core.
+const (
+	// TypeTransfer represents the default type of transaction which moves value
+	// between two accounts. Transactions without a type are transfers.
+	TypeTransfer = "transfer"
+
+	// TypeRotateKey migrates an account to a new key. The balance is moved to
+	// the to account and the two accounts are linked in the state.
+	TypeRotateKey = "rotate_key"
+
+	// TypeSetGuardians registers the guardians that can recover an account
+	// whose key was lost. The data holds the GuardiansData. The to account
+	// isn't used and is normally the from account.
+	TypeSetGuardians = "set_guardians"
+
+	// TypeRecoverAccount is sent by a guardian to approve the recovery of the
+	// to account to a new key. The data holds the RecoveryData.
+	TypeRecoverAccount = "recover_account"
+
+	// TypeGrantFee authorizes the to account to have its gas fees paid by
+	// the from account, up to the limit in the FeeGrantData. A limit of zero
+	// revokes the grant.
+	TypeGrantFee = "grant_fee"
+)
+
+// GuardiansData represents the data of a set guardians transaction.
+type GuardiansData struct {
+	Guardians []acc.AccountID `json:"guardians"`
+	Threshold uint16          `json:"threshold"`
+}
+
+// RecoveryData represents the data of a recover account transaction.
+type RecoveryData struct {
+	NewID acc.AccountID `json:"new_id"`
+}
+
+// FeeGrantData represents the data of a grant fee transaction.
+type FeeGrantData struct {
+	Limit amount.Amount `json:"limit"`
+}
+
 // =============================================================================
 
 // Tx is the transactional information between two parties.
 type Tx struct {
 	ChainID uint16        `json:"chain_id"`
+	Type    string        `json:"type,omitempty"`
 	Nonce   uint64        `json:"nonce"`
 	FromID  acc.AccountID `json:"from"`
 	ToID    acc.AccountID `json:"to"`
-	Value   uint64        `json:"value"`
-	Tip     uint64        `json:"tip"`
+	Value   amount.Amount `json:"value"`
+	Tip     amount.Amount `json:"tip"`
 	Data    []byte        `json:"data"`
+
+	// ExpiresAt is the last block number the transaction can be included
+	// in. Zero means the transaction never expires.
+	ExpiresAt uint64 `json:"expires_at,omitempty"`
+
+	// FeeGranter is the account that pays the gas fee through a fee grant
+	// made to the from account.
+	FeeGranter acc.AccountID `json:"fee_granter,omitempty"`
 }
 
 // NewTx constructs a new transaction.
-func NewTx(chainID uint16, nonce uint64, fromID acc.AccountID, toID acc.AccountID, value uint64, tip uint64, data []byte) (Tx, error) {
+func NewTx(chainID uint16, nonce uint64, fromID acc.AccountID, toID acc.AccountID, value amount.Amount, tip amount.Amount, data []byte) (Tx, error) {
 	if !fromID.IsAccountID() {
 		return Tx{}, errors.New("from account is not properly formatted")
 	}
@@ -48,6 +99,22 @@ func NewTx(chainID uint16, nonce uint64, fromID acc.AccountID, toID acc.AccountI
 	return tx, nil
 }
 
+// Expired reports whether the transaction can no longer be included in the
+// block with the specified number.
+func (tx Tx) Expired(blockNumber uint64) bool {
+	return tx.ExpiresAt != 0 && blockNumber > tx.ExpiresAt
+}
+
+// TxType returns the type of the transaction which determines the module
+// that processes it.
+func (tx Tx) TxType() string {
+	if tx.Type == "" {
+		return TypeTransfer
+	}
+
+	return tx.Type
+}
+
 // Sign uses the specified private key to sign the transaction.
 func (tx Tx) Sign(privateKey *ecdsa.PrivateKey) (SignedTx, error) {
 
@@ -71,6 +138,11 @@ func (tx Tx) Sign(privateKey *ecdsa.PrivateKey) (SignedTx, error) {
 
 // =============================================================================
 
+// recoveryCache holds the senders recovered from transaction signatures so
+// each transaction is only recovered once per node, even though it's
+// validated on submission, when a block is built and when a block arrives.
+var recoveryCache = signature.NewRecoveryCache(10_000)
+
 // SignedTx is a signed version of the transaction. This is how clients like
 // a wallet provide transactions for inclusion into the blockchain.
 type SignedTx struct {
@@ -82,7 +154,9 @@ type SignedTx struct {
 
 // Validate verifies the transaction has a proper signature that conforms to our
 // standards. It also checks the from field matches the account that signed the
-// transaction. Last it checks the format of the from and to fields.
+// transaction. Last it checks the format of the from and to fields. Whether
+// the from and to accounts can be the same is up to the module processing the
+// transaction's type.
 func (tx SignedTx) Validate(chainID uint16) error {
 	if tx.ChainID != chainID {
 		return fmt.Errorf("invalid chain id, got[%d] exp[%d]", tx.ChainID, chainID)
@@ -96,15 +170,21 @@ func (tx SignedTx) Validate(chainID uint16) error {
 		return errors.New("to account is not properly formatted")
 	}
 
-	if tx.FromID == tx.ToID {
-		return fmt.Errorf("transaction invalid, sending money to yourself, from %s, to %s", tx.FromID, tx.ToID)
+	if tx.FeeGranter != "" {
+		if !tx.FeeGranter.IsAccountID() {
+			return errors.New("fee granter account is not properly formatted")
+		}
+
+		if tx.FeeGranter == tx.FromID {
+			return errors.New("transaction invalid, an account can't use a fee grant from itself")
+		}
 	}
 
 	if err := signature.VerifySignature(tx.V, tx.R, tx.S); err != nil {
 		return err
 	}
 
-	address, err := signature.FromAddress(tx.Tx, tx.V, tx.R, tx.S)
+	address, err := recoveryCache.FromAddress(tx.Tx, tx.V, tx.R, tx.S)
 	if err != nil {
 		return err
 	}
@@ -132,13 +212,13 @@ func (tx SignedTx) String() string {
 // includes a timestamp and gas fees.
 type BlockTx struct {
 	SignedTx
-	TimeStamp uint64 `json:"timestamp"` // Ethereum: The time the transaction was received.
-	GasPrice  uint64 `json:"gas_price"` // Ethereum: The price of one unit of gas to be paid for fees.
-	GasUnits  uint64 `json:"gas_units"` // Ethereum: The number of units of gas used for this transaction.
+	TimeStamp uint64        `json:"timestamp"` // Ethereum: The time the transaction was received.
+	GasPrice  amount.Amount `json:"gas_price"` // Ethereum: The price of one unit of gas to be paid for fees.
+	GasUnits  uint64        `json:"gas_units"` // Ethereum: The number of units of gas used for this transaction.
 }
 
 // NewBlockTx constructs a new block transaction.
-func NewBlockTx(signedTx SignedTx, gasPrice uint64, unitsOfGas uint64) BlockTx {
+func NewBlockTx(signedTx SignedTx, gasPrice amount.Amount, unitsOfGas uint64) BlockTx {
 	return BlockTx{
 		SignedTx:  signedTx,
 		TimeStamp: uint64(time.Now().UTC().UnixMilli()),
@@ -0,0 +1,96 @@
+package transaction_test
+
+import (
+	"strings"
+	"testing"
+
+	acc "github.com/dudakovict/blockchain/foundation/blockchain/account"
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+	"github.com/dudakovict/blockchain/foundation/blockchain/chaintest"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+)
+
+const toID = acc.AccountID("0xbEE6ACE826eC3DE1B6349888B9151B92522F7F76")
+
+func Test_Validate(t *testing.T) {
+	pk := chaintest.Key(1)
+	fromID := acc.PublicKeyToAccountID(pk.PublicKey)
+
+	type table struct {
+		testCaseID int
+		change     func(tx *transaction.Tx)
+		after      func(tx *transaction.SignedTx)
+		expected   string
+	}
+
+	tt := []table{
+		{testCaseID: 0},
+		{testCaseID: 1, change: func(tx *transaction.Tx) { tx.FeeGranter = toID }},
+		{testCaseID: 2, change: func(tx *transaction.Tx) { tx.ChainID = 2 }, expected: "chain id"},
+		{testCaseID: 3, change: func(tx *transaction.Tx) { tx.ToID = "0x1234" }, expected: "to account"},
+		{testCaseID: 4, change: func(tx *transaction.Tx) { tx.FeeGranter = "0x1234" }, expected: "fee granter"},
+		{testCaseID: 5, change: func(tx *transaction.Tx) { tx.FeeGranter = fromID }, expected: "from itself"},
+		{testCaseID: 6, change: func(tx *transaction.Tx) { tx.FromID = toID }, expected: "doesn't match"},
+		{testCaseID: 7, after: func(tx *transaction.SignedTx) { tx.Value = amount.New(11) }, expected: "doesn't match"},
+		{testCaseID: 8, after: func(tx *transaction.SignedTx) { tx.FeeGranter = toID }, expected: "doesn't match"},
+		{testCaseID: 9, after: func(tx *transaction.SignedTx) { tx.V.SetInt64(27) }, expected: "recovery id"},
+	}
+
+	for _, tst := range tt {
+		tx, err := transaction.NewTx(1, 1, fromID, toID, amount.New(10), amount.New(1), nil)
+		if err != nil {
+			t.Fatalf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+
+		if tst.change != nil {
+			tst.change(&tx)
+		}
+
+		signed, err := tx.Sign(pk)
+		if err != nil {
+			t.Fatalf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+		}
+
+		if tst.after != nil {
+			tst.after(&signed)
+		}
+
+		err = signed.Validate(1)
+
+		switch {
+		case tst.expected == "":
+			if err != nil {
+				t.Errorf("[case:%d] error: unexpected error: %v", tst.testCaseID, err)
+			}
+
+		case err == nil:
+			t.Errorf("[case:%d] error: expected the transaction to be rejected", tst.testCaseID)
+
+		case !strings.Contains(err.Error(), tst.expected):
+			t.Errorf("[case:%d] error: expected an error about %q got %v", tst.testCaseID, tst.expected, err)
+		}
+	}
+}
+
+func Test_Expired(t *testing.T) {
+	type table struct {
+		testCaseID  int
+		expiresAt   uint64
+		blockNumber uint64
+		expected    bool
+	}
+
+	tt := []table{
+		{testCaseID: 0, expiresAt: 0, blockNumber: 1_000_000, expected: false},
+		{testCaseID: 1, expiresAt: 5, blockNumber: 4, expected: false},
+		{testCaseID: 2, expiresAt: 5, blockNumber: 5, expected: false},
+		{testCaseID: 3, expiresAt: 5, blockNumber: 6, expected: true},
+	}
+
+	for _, tst := range tt {
+		tx := transaction.Tx{ExpiresAt: tst.expiresAt}
+		if got := tx.Expired(tst.blockNumber); got != tst.expected {
+			t.Errorf("[case:%d] error: expected expired %t at block %d got %t", tst.testCaseID, tst.expected, tst.blockNumber, got)
+		}
+	}
+}
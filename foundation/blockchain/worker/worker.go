@@ -0,0 +1,308 @@
+// Package worker implements mining, peer updates and syncing for the
+// blockchain.
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dudakovict/blockchain/foundation/blockchain/block"
+	"github.com/dudakovict/blockchain/foundation/blockchain/peer"
+	"github.com/dudakovict/blockchain/foundation/blockchain/proof"
+	"github.com/dudakovict/blockchain/foundation/blockchain/state"
+)
+
+// Worker manages the goroutines that mine blocks, exchange peers and keep
+// the chain in sync with the peers.
+type Worker struct {
+	state        *state.State
+	wg           sync.WaitGroup
+	peerInterval time.Duration
+	shut         chan struct{}
+	startMining  chan bool
+	cancelMining chan bool
+	sync         chan bool
+	evHandler    state.EventHandler
+}
+
+// Run creates a worker, registers it with the state and starts up all the
+// background processes. The chain is synced with the peers before mining
+// can start.
+func Run(st *state.State, peerInterval time.Duration, evHandler state.EventHandler) {
+	w := Worker{
+		state:        st,
+		peerInterval: peerInterval,
+		shut:         make(chan struct{}),
+		startMining:  make(chan bool, 1),
+		cancelMining: make(chan bool, 1),
+		sync:         make(chan bool, 1),
+		evHandler:    evHandler,
+	}
+
+	// Register this worker with the state package.
+	st.Worker = &w
+
+	// Catch up with the peers before anything else happens.
+	w.state.Gossip().ExchangePeers(context.Background())
+	w.runSyncOperation()
+
+	// Load the set of operations we need to run. Mining runs even without
+	// a beneficiary, since one can be set while the node is running.
+	operations := []func(){
+		w.peerOperations,
+		w.miningOperations,
+	}
+
+	// Set waitgroup to match the number of G's we need for the set
+	// of operations we have.
+	g := len(operations)
+	w.wg.Add(g)
+
+	// We don't want to return until we know all the G's are up and running.
+	hasStarted := make(chan bool)
+
+	// Start all the operational G's.
+	for _, op := range operations {
+		go func(op func()) {
+			defer w.wg.Done()
+			hasStarted <- true
+			op()
+		}(op)
+	}
+
+	// Wait for the G's to report they are running.
+	for i := 0; i < g; i++ {
+		<-hasStarted
+	}
+
+	// Mine whatever is already waiting in the mempool.
+	w.SignalStartMining()
+}
+
+// =============================================================================
+// These methods implement the state.Worker interface.
+
+// Shutdown terminates the goroutines performing work.
+func (w *Worker) Shutdown() {
+	w.evHandler("worker: shutdown: started")
+	defer w.evHandler("worker: shutdown: completed")
+
+	w.evHandler("worker: shutdown: signal cancel mining")
+	w.SignalCancelMining()
+
+	w.evHandler("worker: shutdown: terminate goroutines")
+	close(w.shut)
+	w.wg.Wait()
+}
+
+// SignalStartMining starts a mining operation. If there is already a signal
+// pending in the channel, just return since a mining operation will start.
+func (w *Worker) SignalStartMining() {
+	select {
+	case w.startMining <- true:
+	default:
+	}
+	w.evHandler("worker: SignalStartMining: mining signaled")
+}
+
+// SignalCancelMining signals the G executing the runMiningOperation function
+// to stop immediately.
+func (w *Worker) SignalCancelMining() {
+	select {
+	case w.cancelMining <- true:
+	default:
+	}
+	w.evHandler("worker: SignalCancelMining: cancel mining signaled")
+}
+
+// SignalSync starts a sync with the peers. If there is already a signal
+// pending in the channel, just return since a sync will start.
+func (w *Worker) SignalSync() {
+	select {
+	case w.sync <- true:
+	default:
+	}
+	w.evHandler("worker: SignalSync: sync signaled")
+}
+
+// =============================================================================
+
+// isShutdown is used to test if a shutdown has been signaled.
+func (w *Worker) isShutdown() bool {
+	select {
+	case <-w.shut:
+		return true
+	default:
+		return false
+	}
+}
+
+// peerOperations handles finding new peers and syncing with the longest
+// chain on an interval or when signaled.
+func (w *Worker) peerOperations() {
+	w.evHandler("worker: peerOperations: G started")
+	defer w.evHandler("worker: peerOperations: G completed")
+
+	ticker := time.NewTicker(w.peerInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !w.isShutdown() {
+				w.runPeerOperation()
+			}
+		case <-w.sync:
+			if !w.isShutdown() {
+				w.runSyncOperation()
+			}
+		case <-w.shut:
+			return
+		}
+	}
+}
+
+// runPeerOperation exchanges peer lists with the known peers and then syncs
+// the chain with them.
+func (w *Worker) runPeerOperation() {
+	defer w.state.Recover("worker: runPeerOperation")
+
+	w.state.Gossip().ExchangePeers(context.Background())
+	w.runSyncOperation()
+}
+
+// runSyncOperation syncs the chain with the peers. Mining is cancelled
+// first since the latest block is about to change.
+func (w *Worker) runSyncOperation() {
+	defer w.state.Recover("worker: runSyncOperation")
+
+	w.SignalCancelMining()
+
+	if err := w.state.Sync(context.Background()); err != nil && !errors.Is(err, peer.ErrSyncInProgress) {
+		w.evHandler("worker: runSyncOperation: ERROR: %s", err)
+	}
+
+	w.SignalStartMining()
+}
+
+// miningOperations handles mining.
+func (w *Worker) miningOperations() {
+	w.evHandler("worker: miningOperations: G started")
+	defer w.evHandler("worker: miningOperations: G completed")
+
+	for {
+		select {
+		case <-w.startMining:
+			if !w.isShutdown() {
+				w.runMiningOperation()
+			}
+		case <-w.shut:
+			return
+		}
+	}
+}
+
+// runMiningOperation takes all the transactions from the mempool and writes
+// a new block to the database.
+func (w *Worker) runMiningOperation() {
+	w.evHandler("worker: runMiningOperation: MINING: started")
+	defer w.evHandler("worker: runMiningOperation: MINING: completed")
+	defer w.state.Recover("worker: runMiningOperation")
+
+	// Only nodes with a beneficiary mine blocks, and not while paused.
+	if w.state.BeneficiaryID() == "" || w.state.MiningPaused() {
+		w.evHandler("worker: runMiningOperation: MINING: disabled")
+		return
+	}
+
+	// Make sure there are transactions in the mempool.
+	if w.state.Mempool().Count() == 0 {
+		w.evHandler("worker: runMiningOperation: MINING: no transactions to mine")
+		return
+	}
+
+	// After running a mining operation, check if a new operation should
+	// be signaled again. When the transactions can't be mined until the
+	// chain moves on, the next block or transaction to arrive signals the
+	// next operation instead, otherwise this would spin.
+	retry := true
+	defer func() {
+		if retry && w.state.Mempool().Count() > 0 && !w.isShutdown() {
+			w.SignalStartMining()
+		}
+	}()
+
+	// Drain the cancel mining channel before starting.
+	select {
+	case <-w.cancelMining:
+		w.evHandler("worker: runMiningOperation: MINING: drained cancel channel")
+	default:
+	}
+
+	// Create a context so mining can be cancelled.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Can't return from this function until these G's are complete.
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	// This G exists to cancel the mining operation.
+	go func() {
+		defer func() {
+			cancel()
+			wg.Done()
+		}()
+		select {
+		case <-w.cancelMining:
+			w.evHandler("worker: runMiningOperation: MINING: CANCEL: requested")
+		case <-ctx.Done():
+		}
+	}()
+
+	// This G is performing the mining.
+	go func() {
+		defer func() {
+			cancel()
+			wg.Done()
+		}()
+		defer w.state.Recover("worker: runMiningOperation")
+
+		// A panic while mining isn't retried, since it would happen again
+		// with the same transactions.
+		retry = false
+		b, err := w.state.MineNewBlock(ctx)
+		retry = true
+
+		if err != nil {
+			switch {
+			case errors.Is(err, state.ErrNoTransactions):
+				w.evHandler("worker: runMiningOperation: MINING: WARNING: no transactions in mempool")
+			case errors.Is(err, state.ErrMiningDisabled):
+				w.evHandler("worker: runMiningOperation: MINING: disabled")
+				retry = false
+			case errors.Is(err, proof.ErrNotAuthority):
+				w.evHandler("worker: runMiningOperation: MINING: WARNING: signer isn't an authority")
+				retry = false
+			case errors.Is(err, block.ErrNoTransactionsFit):
+				w.evHandler("worker: runMiningOperation: MINING: WARNING: no transactions fit in the block")
+				retry = false
+			case ctx.Err() != nil:
+				w.evHandler("worker: runMiningOperation: MINING: CANCEL: complete")
+			default:
+				w.evHandler("worker: runMiningOperation: MINING: ERROR: %s", err)
+			}
+			return
+		}
+
+		// The block is mined and committed, share it with the peers.
+		if err := w.state.Gossip().SendBlock(context.Background(), b); err != nil {
+			w.evHandler("worker: runMiningOperation: MINING: SendBlock: ERROR: %s", err)
+		}
+	}()
+
+	// Wait for both G's to terminate.
+	wg.Wait()
+}
@@ -0,0 +1,79 @@
+package worker_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dudakovict/blockchain/foundation/blockchain/amount"
+	"github.com/dudakovict/blockchain/foundation/blockchain/chaintest"
+	"github.com/dudakovict/blockchain/foundation/blockchain/genesis"
+	"github.com/dudakovict/blockchain/foundation/blockchain/peer"
+	"github.com/dudakovict/blockchain/foundation/blockchain/state"
+	"github.com/dudakovict/blockchain/foundation/blockchain/storage/memory"
+	"github.com/dudakovict/blockchain/foundation/blockchain/transaction"
+	"github.com/dudakovict/blockchain/foundation/blockchain/worker"
+)
+
+// Test_Run checks a transaction accepted by a running node is mined without
+// anything else signaling the worker.
+func Test_Run(t *testing.T) {
+	gen := genesis.Genesis{
+		ChainID:    1,
+		Difficulty: 2,
+		GasPrice:   amount.New(1),
+		Balances:   map[string]amount.Amount{string(chaintest.AccountID(1)): amount.New(1000)},
+	}
+
+	cfg := state.Config{
+		BeneficiaryID: chaintest.AccountID(2),
+		MiningWorkers: 2,
+		Host:          "0.0.0.0:9080",
+		Storage:       memory.New(),
+		Genesis:       gen,
+		KnownPeers:    peer.NewPeerSet(),
+	}
+
+	st, err := state.New(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	worker.Run(st, time.Hour, func(v string, args ...any) {})
+
+	tx, err := transaction.NewTx(1, 1, chaintest.AccountID(1), chaintest.AccountID(3), amount.New(100), amount.New(0), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	signed, err := tx.Sign(chaintest.Key(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := st.UpsertWalletTransaction(signed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for st.DB().LatestBlock().Header.Number == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("error: expected the worker to mine the transaction")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Shutting down the state stops the worker before the storage closes.
+	done := make(chan error)
+	go func() {
+		done <- st.Shutdown()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("error: unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("error: expected the worker to shut down")
+	}
+}